@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Cau hinh kiem tra process dang mo file nhay cam
+type AccessConfig struct {
+	Enabled         bool         `json:"enabled"`
+	Paths           []string     `json:"paths"`            // file hoac thu muc nhay cam
+	AllowedReaders  []ReaderRule `json:"allowed_readers"`  // process/user duoc phep mo
+	IntervalSeconds int          `json:"interval_seconds"` // chu ky kiem tra, mac dinh 10s
+}
+
+// Process duoc phep doc file nhay cam, cac truong de trong thi khong kiem tra
+type ReaderRule struct {
+	Exe  string `json:"exe"`  // duong dan exe, ho tro glob
+	Name string `json:"name"` // ten process
+	User string `json:"user"`
+	Path string `json:"path"` // chi ap dung cho file/thu muc nay, de trong la moi path nhay cam
+}
+
+var reportedAccess = make(map[string]bool) // pid|path -> da canh bao
+
+func (c AccessConfig) interval() time.Duration {
+	if c.IntervalSeconds <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(c.IntervalSeconds) * time.Second
+}
+
+// Kiem tra path la file nhay cam hoac nam trong thu muc nhay cam
+func isSensitivePath(path string) bool {
+	for _, p := range config.SensitiveAccess.Paths {
+		if path == p || isUnder(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r ReaderRule) allows(info ProcessInfo, path string) bool {
+	if r.Path != "" && path != r.Path && !isUnder(path, r.Path) {
+		return false
+	}
+	if r.Exe != "" {
+		if ok, _ := filepath.Match(r.Exe, info.Exe); !ok {
+			return false
+		}
+	}
+	if r.Name != "" && !strings.EqualFold(r.Name, filepath.Base(info.Exe)) {
+		return false
+	}
+	if r.User != "" && r.User != info.User {
+		return false
+	}
+	return r.Exe != "" || r.Name != "" || r.User != ""
+}
+
+func isAllowedReader(info ProcessInfo, path string) bool {
+	for _, rule := range config.SensitiveAccess.AllowedReaders {
+		if rule.allows(info, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ham auditSensitiveAccess liet ke process dang mo file nhay cam va canh bao process ngoai allowlist
+func auditSensitiveAccess() {
+	holders := findOpenFileHolders(isSensitivePath)
+	seen := make(map[string]bool)
+	pids := make([]int, 0, len(holders))
+	for pid := range holders {
+		pids = append(pids, pid)
+	}
+	sort.Ints(pids)
+	for _, pid := range pids {
+		if pid == os.Getpid() {
+			continue
+		}
+		info, ok := readProcessInfo(pid)
+		if !ok {
+			continue // process da ket thuc
+		}
+		info.Source = "proc"
+		for _, path := range holders[pid] {
+			key := fmt.Sprintf("%d|%s", pid, path)
+			seen[key] = true
+			if reportedAccess[key] || isAllowedReader(info, path) {
+				continue
+			}
+			reportedAccess[key] = true
+			fmt.Printf("\nALERT: Sensitive file %s is open by %s\n", safeName(path), info)
+		}
+	}
+	// process da dong file: neu mo lai se canh bao lai
+	for key := range reportedAccess {
+		if !seen[key] {
+			delete(reportedAccess, key)
+		}
+	}
+}
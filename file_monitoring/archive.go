@@ -0,0 +1,303 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Cau hinh kiem tra noi dung file nen (zip, tar, gzip)
+type ArchiveConfig struct {
+	Enabled      bool  `json:"enabled"`
+	MaxDepth     int   `json:"max_depth"`      // so cap nen long nhau toi da
+	MaxMembers   int   `json:"max_members"`    // so file toi da trong mot archive
+	MaxTotalSize int64 `json:"max_total_size"` // tong dung luong giai nen toi da (bytes)
+	MaxRatio     int64 `json:"max_ratio"`      // ti le nen toi da cua mot file zip
+}
+
+// Mot file ben trong archive
+type archiveMember struct {
+	Name     string // duong dan long nhau, vd: a.zip!/b.tar!/x.exe
+	Size     int64
+	Findings []string
+}
+
+// Ket qua kiem tra mot archive
+type archiveReport struct {
+	Members  []archiveMember
+	Warnings []string // canh bao muc archive: vuot gioi han, nghi ngo archive bomb...
+	total    int64
+	stopped  bool
+}
+
+const (
+	archiveZip  = "zip"
+	archiveTar  = "tar"
+	archiveGzip = "gzip"
+)
+
+func (c ArchiveConfig) maxDepth() int {
+	if c.MaxDepth <= 0 {
+		return 3
+	}
+	return c.MaxDepth
+}
+
+func (c ArchiveConfig) maxMembers() int {
+	if c.MaxMembers <= 0 {
+		return 1000
+	}
+	return c.MaxMembers
+}
+
+func (c ArchiveConfig) maxTotalSize() int64 {
+	if c.MaxTotalSize <= 0 {
+		return 256 << 20
+	}
+	return c.MaxTotalSize
+}
+
+func (c ArchiveConfig) maxRatio() int64 {
+	if c.MaxRatio <= 0 {
+		return 100
+	}
+	return c.MaxRatio
+}
+
+// Nhan dien loai archive qua magic bytes
+func archiveKind(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")), bytes.HasPrefix(head, []byte("PK\x05\x06")):
+		return archiveZip
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		return archiveGzip
+	case len(head) >= 262 && string(head[257:262]) == "ustar":
+		return archiveTar
+	}
+	return ""
+}
+
+// Nhan dien file thuc thi qua magic bytes
+func executableType(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("MZ")):
+		return "PE"
+	case bytes.HasPrefix(head, []byte("\x7fELF")):
+		return "ELF"
+	case bytes.HasPrefix(head, []byte{0xcf, 0xfa, 0xed, 0xfe}), bytes.HasPrefix(head, []byte{0xce, 0xfa, 0xed, 0xfe}),
+		bytes.HasPrefix(head, []byte{0xca, 0xfe, 0xba, 0xbe}):
+		return "Mach-O"
+	case bytes.HasPrefix(head, []byte("#!")):
+		return "script"
+	}
+	return ""
+}
+
+// Kiem tra hash co nam trong danh sach blocked_hashes
+func isBlockedHash(hash string) bool {
+	for _, h := range config.BlockedHashes {
+		if hash != "" && strings.EqualFold(h, hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ham inspectArchive liet ke cac file ben trong archive, tra ve nil neu path khong phai archive
+func inspectArchive(path string) (*archiveReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open archive: %v", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat archive: %v", err)
+	}
+	head := make([]byte, 512)
+	n, _ := f.ReadAt(head, 0)
+	kind := archiveKind(head[:n])
+	if kind == "" {
+		return nil, nil
+	}
+	report := &archiveReport{}
+	if err := report.scan(kind, f, info.Size(), filepath.Base(path), "", 1); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// Doc noi dung mot member, khong vuot qua tong dung luong cho phep
+func (r *archiveReport) read(rd io.Reader) ([]byte, bool) {
+	remaining := config.ArchiveInspection.maxTotalSize() - r.total
+	data, err := io.ReadAll(io.LimitReader(rd, remaining+1))
+	if int64(len(data)) > remaining {
+		r.total += remaining
+		r.stop(fmt.Sprintf("uncompressed size exceeds %d bytes (possible archive bomb)", config.ArchiveInspection.maxTotalSize()))
+		return nil, false
+	}
+	r.total += int64(len(data))
+	if err != nil {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("unable to read member: %v", err))
+		return nil, false
+	}
+	return data, true
+}
+
+func (r *archiveReport) stop(reason string) {
+	if !r.stopped {
+		r.stopped = true
+		r.Warnings = append(r.Warnings, reason)
+	}
+}
+
+func (r *archiveReport) scan(kind string, ra io.ReaderAt, size int64, name, prefix string, depth int) error {
+	switch kind {
+	case archiveZip:
+		zr, err := zip.NewReader(ra, size)
+		if err != nil {
+			return fmt.Errorf("unable to read zip %s: %v", prefix+name, err)
+		}
+		for _, zf := range zr.File {
+			if r.stopped {
+				return nil
+			}
+			if zf.FileInfo().IsDir() {
+				continue
+			}
+			if zf.CompressedSize64 > 0 && zf.UncompressedSize64/zf.CompressedSize64 > uint64(config.ArchiveInspection.maxRatio()) {
+				r.stop(fmt.Sprintf("%s%s has compression ratio above %d (possible zip bomb)", prefix, zf.Name, config.ArchiveInspection.maxRatio()))
+				return nil
+			}
+			rc, err := zf.Open()
+			if err != nil {
+				r.Warnings = append(r.Warnings, fmt.Sprintf("unable to open %s%s: %v", prefix, zf.Name, err))
+				continue
+			}
+			data, ok := r.read(rc)
+			rc.Close()
+			if ok {
+				r.addMember(prefix+zf.Name, data, depth)
+			}
+		}
+	case archiveTar:
+		tr := tar.NewReader(io.NewSectionReader(ra, 0, size))
+		for !r.stopped {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("unable to read tar %s: %v", prefix+name, err)
+			}
+			if hdr.Typeflag != tar.TypeReg {
+				continue
+			}
+			if data, ok := r.read(tr); ok {
+				r.addMember(prefix+hdr.Name, data, depth)
+			}
+		}
+	case archiveGzip:
+		gr, err := gzip.NewReader(io.NewSectionReader(ra, 0, size))
+		if err != nil {
+			return fmt.Errorf("unable to read gzip %s: %v", prefix+name, err)
+		}
+		defer gr.Close()
+		data, ok := r.read(gr)
+		if !ok {
+			return nil
+		}
+		// .tar.gz: kiem tra tiep tar ben trong o cung cap
+		if archiveKind(data) == archiveTar {
+			return r.scan(archiveTar, bytes.NewReader(data), int64(len(data)), name, prefix, depth)
+		}
+		inner := strings.TrimSuffix(name, filepath.Ext(name))
+		if gr.Name != "" {
+			inner = gr.Name
+		}
+		r.addMember(prefix+inner, data, depth)
+	}
+	return nil
+}
+
+// Ap dung luat extension/type/hash cho member va kiem tra tiep neu member cung la archive
+func (r *archiveReport) addMember(name string, data []byte, depth int) {
+	if len(r.Members) >= config.ArchiveInspection.maxMembers() {
+		r.stop(fmt.Sprintf("more than %d members, listing stopped", config.ArchiveInspection.maxMembers()))
+		return
+	}
+	member := archiveMember{Name: name, Size: int64(len(data))}
+	if len(config.FileExtensions) > 0 {
+		ext := filepath.Ext(name)
+		validExt := false
+		for _, e := range config.FileExtensions {
+			if strings.EqualFold(ext, e) {
+				validExt = true
+				break
+			}
+		}
+		if !validExt {
+			member.Findings = append(member.Findings, fmt.Sprintf("extension %q not in file_extensions", ext))
+		}
+	}
+	member.Findings = append(member.Findings, analyzeFilename(filepath.Base(name))...)
+	if t := executableType(data); t != "" {
+		member.Findings = append(member.Findings, "executable ("+t+")")
+	}
+	if isBlockedHash(fmt.Sprintf("%x", sha256.Sum256(data))) {
+		member.Findings = append(member.Findings, "matches blocked hash")
+	}
+	r.Members = append(r.Members, member)
+
+	if kind := archiveKind(data); kind != "" {
+		if depth >= config.ArchiveInspection.maxDepth() {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("%s not inspected: nesting deeper than %d", name, config.ArchiveInspection.maxDepth()))
+			return
+		}
+		if err := r.scan(kind, bytes.NewReader(data), int64(len(data)), filepath.Base(name), name+"!/", depth+1); err != nil {
+			r.Warnings = append(r.Warnings, err.Error())
+		}
+	}
+}
+
+// Tao cac dong mo ta archive de hien thi trong prompt
+func (r *archiveReport) details() []string {
+	flagged := 0
+	for _, m := range r.Members {
+		if len(m.Findings) > 0 {
+			flagged++
+		}
+	}
+	lines := []string{fmt.Sprintf("Archive contains %d member(s), %d flagged:", len(r.Members), flagged)}
+	shown := 0
+	for _, m := range r.Members {
+		// archive lon: chi liet ke cac member bi gan co
+		if len(r.Members) > 50 && len(m.Findings) == 0 {
+			continue
+		}
+		if shown == 50 {
+			lines = append(lines, "  ... more members not shown")
+			break
+		}
+		shown++
+		line := fmt.Sprintf("  %s (%d bytes)", safeName(m.Name), m.Size)
+		if len(m.Findings) > 0 {
+			line += " [" + strings.Join(m.Findings, ", ") + "]"
+		}
+		lines = append(lines, line)
+	}
+	if len(r.Members) > 50 {
+		lines = append(lines, fmt.Sprintf("  (%d unflagged members not shown)", len(r.Members)-flagged))
+	}
+	for _, w := range r.Warnings {
+		lines = append(lines, "  Warning: "+w)
+	}
+	return lines
+}
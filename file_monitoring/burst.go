@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Cac loai su kien file giua hai lan quet
+const (
+	eventCreate = "create"
+	eventModify = "modify"
+	eventDelete = "delete"
+	eventRename = "rename"
+)
+
+// Su kien thay doi file phat hien giua hai lan quet
+type fileEvent struct {
+	Kind    string
+	Path    string
+	OldPath string // chi co voi su kien rename
+	Folder  string // folder giam sat chua file
+	Time    time.Time
+}
+
+// Nguong phat hien ransomware (thay doi/doi ten hang loat) cho mot folder
+type BurstRule struct {
+	Folder               string   `json:"folder"`                // de trong de ap dung cho moi folder
+	WindowSeconds        int      `json:"window_seconds"`        // do rong cua so truot
+	MaxEvents            int      `json:"max_events"`            // so su kien toi da trong cua so
+	SuspiciousExtensions []string `json:"suspicious_extensions"` // vd: .locked, .encrypted
+	MaxSuspicious        int      `json:"max_suspicious"`        // so file mang duoi dang ngo toi da trong cua so
+	SuspendProcesses     bool     `json:"suspend_processes"`     // tam dung (SIGSTOP) cac process dang mo file trong folder
+}
+
+var defaultRansomExtensions = []string{".locked", ".encrypted", ".enc", ".crypt", ".crypted", ".locky", ".wncry"}
+
+var (
+	burstHistory  = make(map[string][]fileEvent) // folder -> cac su kien con nam trong cua so
+	incidentUntil = make(map[string]time.Time)   // folder -> thoi diem ket thuc su co dang mo
+)
+
+func (r BurstRule) window() time.Duration {
+	if r.WindowSeconds <= 0 {
+		return 60 * time.Second
+	}
+	return time.Duration(r.WindowSeconds) * time.Second
+}
+
+func (r BurstRule) isSuspicious(ev fileEvent) bool {
+	if ev.Kind != eventCreate && ev.Kind != eventRename {
+		return false
+	}
+	exts := r.SuspiciousExtensions
+	if len(exts) == 0 {
+		exts = defaultRansomExtensions
+	}
+	ext := filepath.Ext(ev.Path)
+	for _, e := range exts {
+		if strings.EqualFold(ext, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// Lay rule cho folder, uu tien rule khai bao dung folder, sau do den rule mac dinh (folder rong)
+func burstRuleFor(folder string) (BurstRule, bool) {
+	var fallback *BurstRule
+	for i, rule := range config.BurstRules {
+		if rule.Folder == "" {
+			if fallback == nil {
+				fallback = &config.BurstRules[i]
+			}
+			continue
+		}
+		if filepath.Clean(rule.Folder) == filepath.Clean(folder) {
+			return rule, true
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return BurstRule{}, false
+}
+
+// Ham maxInWindow tra ve so su kien lon nhat nam trong mot cua so do rong window
+func maxInWindow(events []fileEvent, window time.Duration, match func(fileEvent) bool) (int, []fileEvent) {
+	var selected []fileEvent
+	for _, ev := range events {
+		if match == nil || match(ev) {
+			selected = append(selected, ev)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Time.Before(selected[j].Time) })
+	best, bestStart, start := 0, 0, 0
+	for end := range selected {
+		for selected[end].Time.Sub(selected[start].Time) > window {
+			start++
+		}
+		if end-start+1 > best {
+			best, bestStart = end-start+1, start
+		}
+	}
+	return best, selected[bestStart : bestStart+best]
+}
+
+// Ham detectBursts dua su kien vao cua so truot cua tung folder va tra ve cac folder dang co su co
+func detectBursts(events []fileEvent, now time.Time) map[string]bool {
+	for _, ev := range events {
+		if ev.Folder != "" {
+			burstHistory[ev.Folder] = append(burstHistory[ev.Folder], ev)
+		}
+	}
+
+	incidents := make(map[string]bool)
+	for _, folder := range config.MonitorFolder {
+		rule, ok := burstRuleFor(folder)
+		if !ok {
+			continue
+		}
+		window := rule.window()
+
+		// chi giu lai su kien trong cua so + mot chu ky quet (mtime co the som hon luc quet)
+		var kept []fileEvent
+		for _, ev := range burstHistory[folder] {
+			if now.Sub(ev.Time) <= window+checkInterval {
+				kept = append(kept, ev)
+			}
+		}
+		burstHistory[folder] = kept
+
+		if now.Before(incidentUntil[folder]) {
+			incidents[folder] = true
+			continue
+		}
+
+		total, burst := maxInWindow(kept, window, nil)
+		suspicious, _ := maxInWindow(kept, window, rule.isSuspicious)
+		if (rule.MaxEvents > 0 && total >= rule.MaxEvents) || (rule.MaxSuspicious > 0 && suspicious >= rule.MaxSuspicious) {
+			raiseBurstIncident(folder, rule, burst, suspicious)
+			incidentUntil[folder] = now.Add(window + checkInterval)
+			incidents[folder] = true
+		}
+	}
+	return incidents
+}
+
+// Bao mot su co duy nhat cho ca dot thay doi, khong hoi tung file
+func raiseBurstIncident(folder string, rule BurstRule, burst []fileEvent, suspicious int) {
+	fmt.Printf("\nCRITICAL: Possible ransomware activity in %s: %d file events within %v (%d with suspicious extensions)\n",
+		folder, len(burst), rule.window(), suspicious)
+	for i, ev := range burst {
+		if i == 10 {
+			fmt.Printf("  ... and %d more\n", len(burst)-i)
+			break
+		}
+		if ev.Kind == eventRename {
+			fmt.Printf("  %s: %s -> %s\n", ev.Kind, safeName(ev.OldPath), safeName(ev.Path))
+		} else {
+			fmt.Printf("  %s: %s\n", ev.Kind, safeName(ev.Path))
+		}
+	}
+	if rule.SuspendProcesses {
+		suspendFileHolders(folder)
+	}
+}
+
+// Tam dung cac process dang mo file trong folder
+func suspendFileHolders(folder string) {
+	prefix := strings.TrimSuffix(folder, string(os.PathSeparator)) + string(os.PathSeparator)
+	holders := findOpenFileHolders(func(path string) bool {
+		return strings.HasPrefix(path, prefix)
+	})
+	if len(holders) == 0 {
+		fmt.Printf("No process is holding files open in %s\n", folder)
+		return
+	}
+	for pid, files := range holders {
+		if pid == os.Getpid() {
+			continue
+		}
+		if err := suspendProcess(pid); err != nil {
+			fmt.Printf("Unable to suspend process %d: %v\n", pid, err)
+		} else {
+			fmt.Printf("Suspended process %d holding %d file(s) open, e.g. %s\n", pid, len(files), safeName(files[0]))
+		}
+	}
+}
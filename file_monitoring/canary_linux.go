@@ -0,0 +1,91 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const canaryMask = syscall.IN_ACCESS | syscall.IN_OPEN | syscall.IN_MODIFY | syscall.IN_ATTRIB |
+	syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+var (
+	inotifyOnce  sync.Once
+	inotifyFd    = -1
+	inotifyErr   error
+	inotifyMu    sync.Mutex
+	canaryWatchs = make(map[int32]string) // watch descriptor -> canary path
+)
+
+// Ham watchCanary them canary vao inotify, khoi tao goroutine doc su kien o lan goi dau
+func watchCanary(path string) error {
+	inotifyOnce.Do(func() {
+		inotifyFd, inotifyErr = syscall.InotifyInit1(syscall.IN_CLOEXEC)
+		if inotifyErr == nil {
+			go readInotify(inotifyFd)
+		}
+	})
+	if inotifyErr != nil {
+		return inotifyErr
+	}
+	wd, err := syscall.InotifyAddWatch(inotifyFd, path, canaryMask)
+	if err != nil {
+		return err
+	}
+	inotifyMu.Lock()
+	canaryWatchs[int32(wd)] = path
+	inotifyMu.Unlock()
+	return nil
+}
+
+func describeInotify(mask uint32) string {
+	var actions []string
+	if mask&syscall.IN_OPEN != 0 {
+		actions = append(actions, "opened")
+	}
+	if mask&syscall.IN_ACCESS != 0 {
+		actions = append(actions, "read")
+	}
+	if mask&syscall.IN_MODIFY != 0 {
+		actions = append(actions, "modified")
+	}
+	if mask&syscall.IN_ATTRIB != 0 {
+		actions = append(actions, "changed attributes")
+	}
+	if mask&(syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF) != 0 {
+		actions = append(actions, "deleted or moved")
+	}
+	return strings.Join(actions, ", ")
+}
+
+// Doc su kien inotify va bao dong cho canary tuong ung
+func readInotify(fd int) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := syscall.Read(fd, buf)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil || n <= 0 {
+			fmt.Printf("Warning: inotify stopped: %v\n", err)
+			return
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			inotifyMu.Lock()
+			path := canaryWatchs[event.Wd]
+			if event.Mask&syscall.IN_IGNORED != 0 {
+				delete(canaryWatchs, event.Wd) // canary bi xoa, se duoc dat lai o lan kiem tra sau
+			}
+			inotifyMu.Unlock()
+			if path != "" && event.Mask&canaryMask != 0 {
+				raiseCanaryAlert(path, describeInotify(event.Mask))
+			}
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+		}
+	}
+}
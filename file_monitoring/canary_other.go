@@ -0,0 +1,12 @@
+//go:build !linux
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func watchCanary(path string) error {
+	return fmt.Errorf("inotify is not supported on %s", runtime.GOOS)
+}
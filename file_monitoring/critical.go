@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func storeDir() string {
+	if config.StoreDir == "" {
+		return "store"
+	}
+	return config.StoreDir
+}
+
+// Ban sao duoc luu theo hash noi dung
+func storePath(hash string) string {
+	return filepath.Join(storeDir(), hash)
+}
+
+// File thuoc danh sach critical_files (duong dan hoac glob)
+func isCritical(path string) bool {
+	for _, pattern := range config.CriticalFiles {
+		if path == pattern {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Ham storePristine luu ban sao noi dung file da duyet vao store (neu chua co)
+func storePristine(path string, state FileState) error {
+	if state.Hash == "" {
+		return fmt.Errorf("file has no hash")
+	}
+	if _, err := os.Stat(storePath(state.Hash)); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(storeDir(), 0700); err != nil {
+		return fmt.Errorf("unable to create store dir: %v", err)
+	}
+	tmp := storePath(state.Hash) + ".tmp"
+	if err := copyFile(path, tmp, 0600); err != nil {
+		return err
+	}
+	// file co the bi sua trong luc copy
+	if hash, err := getFileHash(tmp); err != nil || hash != state.Hash {
+		os.Remove(tmp)
+		return fmt.Errorf("content of %s changed while storing it", path)
+	}
+	return os.Rename(tmp, storePath(state.Hash))
+}
+
+// Ham restoreFile khoi phuc noi dung, quyen va chu so huu cua file tu ban sao trong store
+func restoreFile(path string, approved FileState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("unable to create parent dir: %v", err)
+	}
+	in, err := os.Open(storePath(approved.Hash))
+	if err != nil {
+		return fmt.Errorf("unable to open stored copy: %v", err)
+	}
+	defer in.Close()
+	// thu muc giam sat co the cho nguoi khac ghi: file tam co ten ngau nhien va duoc tao moi (O_EXCL),
+	// quyen va chu so huu dat qua file descriptor de khong di theo symlink dat san
+	out, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".restore-*")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file: %v", err)
+	}
+	tmp := out.Name()
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("unable to copy stored copy: %v", err)
+	}
+	if err := out.Chmod(approved.Mode.Perm()); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("unable to restore mode: %v", err)
+	}
+	if approved.UID >= 0 && approved.GID >= 0 {
+		if err := out.Chown(approved.UID, approved.GID); err != nil {
+			fmt.Printf("Warning: Cannot restore ownership of %s: %v\n", safeName(path), err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("unable to write temporary file: %v", err)
+	}
+	if err := os.Chtimes(tmp, time.Now(), approved.ModTime); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("unable to restore modification time: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("unable to replace file: %v", err)
+	}
+	return nil
+}
+
+// Ham restoreCriticalFiles so sanh file critical voi trang thai da duyet va tu dong khoi phuc khi bi sua/xoa
+func restoreCriticalFiles(current map[string]FileState) {
+	if len(config.CriticalFiles) == 0 {
+		return
+	}
+	for path := range baseline.KnownFiles {
+		approved, ok := baseline.FileStates[path]
+		if !ok || approved.Hash == "" || !isCritical(path) || folderOf(path) == "" {
+			continue
+		}
+		cur, exists := current[path]
+		if !exists {
+			if _, err := os.Lstat(path); err == nil {
+				continue // file nam trong thu muc bi bo qua, khong quet
+			}
+		}
+		if exists && sameContent(cur, approved) {
+			continue
+		}
+		if _, err := os.Stat(storePath(approved.Hash)); err != nil {
+			fmt.Printf("\nALERT: Critical file %s changed but has no pristine copy, cannot restore it\n", safeName(path))
+			rejectedChanges[path] = true
+			continue
+		}
+
+		if exists {
+			fmt.Printf("\nALERT: Critical file %s was tampered with, restoring approved version\n", safeName(path))
+			if cur.Hash != approved.Hash {
+				if dest, err := quarantineCopy(path); err != nil {
+					fmt.Printf("Unable to quarantine tampered %s: %v\n", safeName(path), err)
+				} else {
+					fmt.Printf("Tampered version saved to %s\n", dest)
+				}
+			}
+		} else {
+			fmt.Printf("\nALERT: Critical file %s was deleted, restoring approved version\n", safeName(path))
+		}
+		if err := restoreFile(path, approved); err != nil {
+			fmt.Printf("Unable to restore %s: %v\n", safeName(path), err)
+			rejectedChanges[path] = true
+			continue
+		}
+		if info, err := os.Lstat(path); err == nil {
+			current[path] = getFileState(path, info, approved, true)
+		}
+		delete(rejectedChanges, path)
+		fmt.Printf("Restored critical file %s\n", safeName(path))
+	}
+}
+
+// Ham sameContent so sanh noi dung, quyen va chu so huu cua file
+func sameContent(a, b FileState) bool {
+	return a.Hash == b.Hash && a.Mode == b.Mode && a.UID == b.UID && a.GID == b.GID
+}
+
+// Ham checkRejectedChanges canh bao moi lan quet khi thay doi bi tu choi van con, bo khi file tro lai ban da duyet
+func checkRejectedChanges(current map[string]FileState, events []fileEvent) {
+	modified := make(map[string]bool)
+	for _, ev := range events {
+		if ev.Kind == eventModify {
+			modified[ev.Path] = true
+		}
+	}
+	for path := range rejectedChanges {
+		cur, exists := current[path]
+		if !exists || !baseline.KnownFiles[path] || sameContent(cur, baseline.FileStates[path]) {
+			delete(rejectedChanges, path)
+			continue
+		}
+		// file critical duoc canh bao trong restoreCriticalFiles, file vua sua lai se duoc xem xet lai
+		if !modified[path] && !isCritical(path) {
+			fmt.Printf("\nALERT: Rejected modification of %s is still present, approved version kept in baseline\n", safeName(path))
+		}
+	}
+}
+
+// Ham storeApprovedCopies luu ban sao cho file critical va file van ban nho (de tao diff) vua duoc duyet
+func storeApprovedCopies(current map[string]FileState) {
+	if len(config.CriticalFiles) == 0 && !config.Diff.Enabled {
+		return
+	}
+	for path := range baseline.KnownFiles {
+		approved, ok := baseline.FileStates[path]
+		cur, exists := current[path]
+		if !ok || !exists || approved.Hash == "" || cur.Hash != approved.Hash {
+			continue
+		}
+		if _, err := os.Stat(storePath(cur.Hash)); err == nil {
+			continue
+		}
+		if !isCritical(path) && !keepsTextCopy(path, cur) {
+			continue
+		}
+		if err := storePristine(path, cur); err != nil {
+			fmt.Printf("Unable to store pristine copy of %s: %v\n", safeName(path), err)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Cau hinh hien thi diff cho file van ban bi sua
+type DiffConfig struct {
+	Enabled        bool  `json:"enabled"`
+	MaxFileSize    int64 `json:"max_file_size"`    // chi luu noi dung file nho hon (bytes)
+	MaxLines       int   `json:"max_lines"`        // so dong diff toi da hien thi
+	ContextLines   int   `json:"context_lines"`    // so dong ngu canh quanh thay doi
+	PromptOnModify bool  `json:"prompt_on_modify"` // hoi duyet thay doi, tu choi thi khoi phuc ban cu
+}
+
+// Mot dong trong edit script: ' ' giu nguyen, '-' xoa, '+' them
+type diffOp struct {
+	Kind byte
+	Text string
+}
+
+// Gioi han bang LCS de tranh ton bo nho voi file lon
+const maxDiffCells = 4 << 20
+
+func (c DiffConfig) maxFileSize() int64 {
+	if c.MaxFileSize <= 0 {
+		return 64 << 10
+	}
+	return c.MaxFileSize
+}
+
+func (c DiffConfig) maxLines() int {
+	if c.MaxLines <= 0 {
+		return 200
+	}
+	return c.MaxLines
+}
+
+func (c DiffConfig) contextLines() int {
+	if c.ContextLines <= 0 {
+		return 3
+	}
+	return c.ContextLines
+}
+
+// File van ban nho thi giu lai noi dung da duyet de tao diff
+func keepsTextCopy(path string, state FileState) bool {
+	if !config.Diff.Enabled || state.Size > config.Diff.maxFileSize() {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	return err == nil && bytes.IndexByte(data, 0) < 0
+}
+
+// Ham diffLines tao edit script giua hai danh sach dong (LCS sau khi bo phan dau/cuoi giong nhau)
+func diffLines(a, b []string) []diffOp {
+	p := 0
+	for p < len(a) && p < len(b) && a[p] == b[p] {
+		p++
+	}
+	s := 0
+	for s < len(a)-p && s < len(b)-p && a[len(a)-1-s] == b[len(b)-1-s] {
+		s++
+	}
+	var ops []diffOp
+	for _, line := range a[:p] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	am, bm := a[p:len(a)-s], b[p:len(b)-s]
+	n, m := len(am), len(bm)
+	if (n+1)*(m+1) <= maxDiffCells {
+		lcs := make([][]int32, n+1)
+		for i := range lcs {
+			lcs[i] = make([]int32, m+1)
+		}
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if am[i] == bm[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < n && j < m {
+			switch {
+			case am[i] == bm[j]:
+				ops = append(ops, diffOp{' ', am[i]})
+				i++
+				j++
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				ops = append(ops, diffOp{'-', am[i]})
+				i++
+			default:
+				ops = append(ops, diffOp{'+', bm[j]})
+				j++
+			}
+		}
+		am, bm = am[i:], bm[j:]
+	}
+	// phan con lai (hoac ca doan giua neu qua lon): xoa het roi them moi
+	for _, line := range am {
+		ops = append(ops, diffOp{'-', line})
+	}
+	for _, line := range bm {
+		ops = append(ops, diffOp{'+', line})
+	}
+	for _, line := range a[len(a)-s:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// Ham unifiedHunks nhom edit script thanh cac hunk "@@ -a,b +c,d @@" voi context dong ngu canh
+func unifiedHunks(ops []diffOp, context int) []string {
+	oldLine, newLine := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	ai, bi := 1, 1
+	for k, op := range ops {
+		oldLine[k], newLine[k] = ai, bi
+		if op.Kind != '+' {
+			ai++
+		}
+		if op.Kind != '-' {
+			bi++
+		}
+	}
+
+	// dong nam giua BEGIN va END PRIVATE KEY (tinh rieng cho ban cu va ban moi) la du lieu cua key
+	keyData := make([]bool, len(ops))
+	inOld, inNew := false, false
+	for k, op := range ops {
+		old, cur := op.Kind != '+', op.Kind != '-'
+		begin, end := privateKeyBegin.MatchString(op.Text), privateKeyEnd.MatchString(op.Text)
+		keyData[k] = (old && inOld || cur && inNew) && !begin && !end
+		if begin && !end {
+			inOld, inNew = inOld || old, inNew || cur
+		}
+		if end {
+			inOld, inNew = inOld && !old, inNew && !cur
+		}
+	}
+
+	var lines []string
+	for k := 0; k < len(ops); {
+		for k < len(ops) && ops[k].Kind == ' ' {
+			k++
+		}
+		if k == len(ops) {
+			break
+		}
+		start, end := max(0, k-context), k
+		for end < len(ops) {
+			if ops[end].Kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].Kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end = min(end+context, len(ops))
+				break
+			}
+			end = run
+		}
+
+		oldCount, newCount := 0, 0
+		for _, op := range ops[start:end] {
+			if op.Kind != '+' {
+				oldCount++
+			}
+			if op.Kind != '-' {
+				newCount++
+			}
+		}
+		oldStart, newStart := oldLine[start], newLine[start]
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		lines = append(lines, fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldStart, oldCount, newStart, newCount))
+		for i, op := range ops[start:end] {
+			text := redactSecrets(op.Text)
+			if keyData[start+i] {
+				text = redactKeyData(op.Text)
+			}
+			lines = append(lines, string(op.Kind)+safeName(text))
+		}
+		k = end
+	}
+	return lines
+}
+
+func splitLines(data []byte) []string {
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// Ham fileDiff tao unified diff giua noi dung da duyet (trong store) va noi dung hien tai
+func fileDiff(path string, approved FileState) ([]string, error) {
+	old, err := os.ReadFile(storePath(approved.Hash))
+	if err != nil {
+		return nil, fmt.Errorf("no stored copy of the approved version")
+	}
+	cur, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file: %v", err)
+	}
+	if int64(len(cur)) > config.Diff.maxFileSize() || bytes.IndexByte(cur, 0) >= 0 {
+		return nil, fmt.Errorf("new content is binary or larger than %d bytes", config.Diff.maxFileSize())
+	}
+	hunks := unifiedHunks(diffLines(splitLines(old), splitLines(cur)), config.Diff.contextLines())
+	lines := []string{"--- " + safeName(path) + " (approved)", "+++ " + safeName(path) + " (current)"}
+	if len(hunks) > config.Diff.maxLines() {
+		lines = append(lines, hunks[:config.Diff.maxLines()]...)
+		return append(lines, fmt.Sprintf("... diff truncated, %d more lines", len(hunks)-config.Diff.maxLines())), nil
+	}
+	return append(lines, hunks...), nil
+}
+
+// Ham reviewModification hien thi diff cua file da duyet bi sua va hoi duyet neu duoc cau hinh
+func reviewModification(path string, current map[string]FileState) {
+	approved := baseline.FileStates[path]
+	lines, err := fileDiff(path, approved)
+	if err != nil {
+		fmt.Printf("No diff available for %s: %v\n", safeName(path), err)
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	if !config.Diff.PromptOnModify {
+		return
+	}
+	fmt.Printf("\nDetect modified file %s\n", safeName(path))
+	if promptChoice("Approve modification? (y/n): ", "y", "n") == "y" {
+		fmt.Printf("Approved modification of %s\n", safeName(path))
+		delete(rejectedChanges, path)
+		return
+	}
+	if _, err := os.Stat(storePath(approved.Hash)); err != nil {
+		fmt.Printf("Modification of %s is NOT approved, but no stored copy is available to restore\n", safeName(path))
+		rejectedChanges[path] = true
+		return
+	}
+	if dest, err := quarantineCopy(path); err == nil {
+		fmt.Printf("Rejected version saved to %s\n", dest)
+	}
+	if err := restoreFile(path, approved); err != nil {
+		fmt.Printf("Unable to restore %s: %v\n", safeName(path), err)
+		rejectedChanges[path] = true
+		return
+	}
+	if info, err := os.Lstat(path); err == nil {
+		current[path] = getFileState(path, info, approved, true)
+	}
+	delete(rejectedChanges, path)
+	fmt.Printf("Restored approved version of %s\n", safeName(path))
+}
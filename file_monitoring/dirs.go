@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Kiem tra path nam ben trong thu muc dir
+func isUnder(path, dir string) bool {
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, string(os.PathSeparator))+string(os.PathSeparator))
+}
+
+// Ham removeEmptyTree xoa thu muc khong co file cung cac thu muc con rong, tu duoi len
+func removeEmptyTree(dir string, dirs map[string]os.FileMode) error {
+	var subdirs []string
+	for sub := range dirs {
+		if isUnder(sub, dir) {
+			subdirs = append(subdirs, sub)
+		}
+	}
+	// duong dan dai hon (sau hon) xoa truoc
+	sort.Slice(subdirs, func(i, j int) bool { return len(subdirs[i]) > len(subdirs[j]) })
+	for _, sub := range append(subdirs, dir) {
+		if err := os.Remove(sub); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ham checkDirectories so sanh cac thu muc dang co voi baseline: thu muc moi, bi xoa, doi quyen
+func checkDirectories(dirs map[string]os.FileMode, files map[string]FileState, incidents map[string]bool) bool {
+	changed := false
+	// baseline cu chua co thu muc: lan quet dau chi ghi nhan
+	if !baseline.DirsRecorded {
+		for dir, mode := range dirs {
+			baseline.KnownDirs[dir] = mode
+		}
+		baseline.DirsRecorded = true
+		return true
+	}
+
+	var known, created []string
+	for dir := range dirs {
+		if _, ok := baseline.KnownDirs[dir]; ok {
+			known = append(known, dir)
+		} else {
+			created = append(created, dir)
+		}
+	}
+	sort.Strings(known)
+	sort.Strings(created)
+
+	for _, dir := range known {
+		if old := baseline.KnownDirs[dir]; old != dirs[dir] {
+			fmt.Printf("\nALERT: Permissions of directory %s changed: %v -> %v\n", safeName(dir), old, dirs[dir])
+			if dirs[dir].Perm()&0002 != 0 && old.Perm()&0002 == 0 {
+				fmt.Printf("ALERT: Directory %s became world-writable\n", safeName(dir))
+			}
+			baseline.KnownDirs[dir] = dirs[dir]
+			changed = true
+		}
+	}
+
+	var deleted []string
+	for dir := range baseline.KnownDirs {
+		if _, ok := dirs[dir]; !ok && folderOf(dir) != "" {
+			deleted = append(deleted, dir)
+		}
+	}
+	// thu muc bi tu choi da bien mat: bo ghi nhan
+	for dir := range baseline.DeniedDirs {
+		if _, ok := dirs[dir]; !ok {
+			delete(baseline.DeniedDirs, dir)
+			changed = true
+		}
+	}
+	sort.Strings(deleted)
+	for _, dir := range deleted {
+		if !incidents[folderOf(dir)] {
+			fmt.Printf("Warning: Approved directory deleted: %s\n", safeName(dir))
+		}
+		delete(baseline.KnownDirs, dir)
+		changed = true
+	}
+
+	for _, dir := range created {
+		if _, ok := baseline.KnownDirs[dir]; ok || incidents[folderOf(dir)] {
+			continue // da duoc duyet cung thu muc cha
+		}
+		if baseline.DeniedDirs[dir] {
+			continue // da tu choi, file ben trong van duoc duyet rieng
+		}
+		if _, err := os.Lstat(dir); os.IsNotExist(err) {
+			continue // da bi xoa cung thu muc cha bi tu choi
+		}
+		fileCount := 0
+		for path := range files {
+			if isUnder(path, dir) {
+				fileCount++
+			}
+		}
+		fmt.Printf("\nDetect new directory %s (%d files, mode %v)\n", safeName(dir), fileCount, dirs[dir])
+		if findings := analyzeFilename(filepath.Base(dir)); len(findings) > 0 {
+			fmt.Printf("ALERT: Deceptive directory name: %s\n", strings.Join(findings, "; "))
+		}
+		switch promptChoice("Approval? (y = directory only, a = whole subtree, n = deny): ", "y", "a", "n") {
+		case "y":
+			baseline.KnownDirs[dir] = dirs[dir]
+			changed = true
+			fmt.Printf("Approved directory %s, its files will be reviewed individually\n", safeName(dir))
+		case "a":
+			baseline.KnownDirs[dir] = dirs[dir]
+			approvedFiles := 0
+			for sub, mode := range dirs {
+				if isUnder(sub, dir) {
+					baseline.KnownDirs[sub] = mode
+				}
+			}
+			for path, state := range files {
+				if isUnder(path, dir) && !baseline.KnownFiles[path] {
+					baseline.KnownFiles[path] = true
+					baseline.FileStates[path] = state
+					approvedFiles++
+				}
+			}
+			changed = true
+			fmt.Printf("Approved directory %s with its whole subtree (%d files)\n", safeName(dir), approvedFiles)
+		default:
+			if fileCount == 0 {
+				if err := removeEmptyTree(dir, dirs); err != nil {
+					// van con noi dung khong duoc quet (file bi bo qua...): ghi nhan de khong hoi lai
+					baseline.DeniedDirs[dir] = true
+					changed = true
+					fmt.Printf("Unable to remove directory %s: %v\n", safeName(dir), err)
+				} else {
+					fmt.Printf("Removed empty directory: %s\n", safeName(dir))
+				}
+			} else {
+				baseline.DeniedDirs[dir] = true
+				changed = true
+				fmt.Printf("Directory %s is NOT approved, its files will be reviewed individually\n", safeName(dir))
+			}
+		}
+	}
+	return changed
+}
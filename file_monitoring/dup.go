@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Chinh sach xu ly file moi trung noi dung voi file da co quyet dinh
+const (
+	duplicateReport      = "report"       // chi hien thi trong prompt (mac dinh)
+	duplicateReuse       = "reuse"        // dung lai quyet dinh duyet/tu choi truoc do
+	duplicateReuseDenied = "reuse_denied" // chi tu dong tu choi ban sao cua file da bi tu choi
+)
+
+// Ham buildHashIndex tao chi muc hash -> cac file da duyet
+func buildHashIndex() map[string][]string {
+	index := make(map[string][]string)
+	for path, state := range baseline.FileStates {
+		if state.Hash != "" && baseline.KnownFiles[path] {
+			index[state.Hash] = append(index[state.Hash], path)
+		}
+	}
+	for hash := range index {
+		sort.Strings(index[hash])
+	}
+	return index
+}
+
+// Ham groupDuplicates nhom cac file moi co cung noi dung, file dau tien dai dien cho ca nhom
+func groupDuplicates(paths []string, states map[string]FileState) (groups map[string][]string, copies map[string]bool) {
+	groups = make(map[string][]string)
+	copies = make(map[string]bool)
+	leaders := make(map[string]string) // hash -> file dai dien
+	for _, path := range paths {
+		hash := states[path].Hash
+		if hash == "" {
+			continue
+		}
+		if leader, ok := leaders[hash]; ok {
+			groups[leader] = append(groups[leader], path)
+			copies[path] = true
+			continue
+		}
+		leaders[hash] = path
+	}
+	return groups, copies
+}
+
+// Tra ve quyet dinh co the dung lai cho hash theo duplicate_policy: "approve", "deny" hoac ""
+func reusedDecision(hash string, index map[string][]string) (decision, reference string) {
+	if hash == "" {
+		return "", ""
+	}
+	if ref, ok := baseline.DeniedHashes[hash]; ok && (config.DuplicatePolicy == duplicateReuse || config.DuplicatePolicy == duplicateReuseDenied) {
+		return "deny", ref
+	}
+	if refs := index[hash]; len(refs) > 0 && config.DuplicatePolicy == duplicateReuse {
+		return "approve", refs[0]
+	}
+	return "", ""
+}
+
+// Cac dong mo ta ban sao de hien thi trong prompt
+func duplicateDetails(hash string, index map[string][]string, copies []string) []string {
+	var details []string
+	if hash == "" {
+		return nil
+	}
+	for _, ref := range index[hash] {
+		details = append(details, "Same content as approved file "+safeName(ref))
+	}
+	if ref, ok := baseline.DeniedHashes[hash]; ok {
+		details = append(details, "Same content as previously denied file "+safeName(ref))
+	}
+	if len(copies) > 0 {
+		details = append(details, fmt.Sprintf("Same content also dropped at %d other location(s), the decision applies to all:", len(copies)))
+		for _, c := range copies {
+			details = append(details, "  "+safeName(c))
+		}
+	}
+	return details
+}
+
+// Ghi nhan hash cua file bi tu choi de nhan ra ban sao ve sau
+func recordDenied(path string, state FileState) {
+	if state.Hash != "" {
+		baseline.DeniedHashes[state.Hash] = path
+	}
+}
+
+// Ap dung cung quyet dinh cho cac ban sao cua file dai dien
+func applyToCopies(copies []string, approved bool, current map[string]FileState) {
+	for _, path := range copies {
+		if approved {
+			baseline.KnownFiles[path] = true
+			baseline.FileStates[path] = current[path]
+			fmt.Printf("Approved duplicate copy: %s\n", safeName(path))
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			fmt.Printf("Unable to remove %s: %v\n", safeName(path), err)
+		} else {
+			delete(current, path)
+			fmt.Printf("Removed duplicate copy: %s\n", safeName(path))
+		}
+	}
+}
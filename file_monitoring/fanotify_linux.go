@@ -0,0 +1,89 @@
+//go:build linux && (amd64 || arm64)
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Hang so trong linux/fanotify.h
+const (
+	fanClassNotif  = 0x0
+	fanCloexec     = 0x1
+	fanMarkAdd     = 0x1
+	fanMarkMount   = 0x10
+	fanModify      = 0x2
+	fanCloseWrite  = 0x8
+	fanEventMetaSz = 24
+	atFdcwd        = -100
+)
+
+// Ham startFanotify dang ky theo doi ghi file tren cac mount chua folder giam sat
+func startFanotify() error {
+	fd, _, errno := syscall.Syscall(syscall.SYS_FANOTIFY_INIT, fanClassNotif|fanCloexec, uintptr(os.O_RDONLY|syscall.O_LARGEFILE), 0)
+	if errno != 0 {
+		return fmt.Errorf("fanotify_init failed: %v", errno)
+	}
+	marked := 0
+	dirfd := atFdcwd
+	for _, folder := range config.MonitorFolder {
+		p, err := syscall.BytePtrFromString(folder)
+		if err != nil {
+			continue
+		}
+		_, _, errno := syscall.Syscall6(syscall.SYS_FANOTIFY_MARK, fd, fanMarkAdd|fanMarkMount, fanModify|fanCloseWrite,
+			uintptr(dirfd), uintptr(unsafe.Pointer(p)), 0)
+		if errno != 0 {
+			fmt.Printf("Warning: Cannot watch %s with fanotify: %v\n", folder, errno)
+			continue
+		}
+		marked++
+	}
+	if marked == 0 {
+		syscall.Close(int(fd))
+		return fmt.Errorf("no folder could be watched with fanotify")
+	}
+	go readFanotify(int(fd))
+	return nil
+}
+
+// Doc su kien fanotify, ghi nhan process ghi file nam trong folder giam sat
+func readFanotify(fd int) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := syscall.Read(fd, buf)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil || n <= 0 {
+			fmt.Printf("Warning: fanotify stopped: %v\n", err)
+			return
+		}
+		for offset := 0; offset+fanEventMetaSz <= n; {
+			eventLen := int(binary.NativeEndian.Uint32(buf[offset:]))
+			eventFd := int(int32(binary.NativeEndian.Uint32(buf[offset+16:])))
+			pid := int(int32(binary.NativeEndian.Uint32(buf[offset+20:])))
+			if eventLen < fanEventMetaSz {
+				break
+			}
+			if eventFd >= 0 {
+				path, err := os.Readlink(fmt.Sprintf("/proc/self/fd/%d", eventFd))
+				syscall.Close(eventFd)
+				if err == nil && folderOf(path) != "" && !strings.HasPrefix(path, quarantineDir()) {
+					if info, ok := readProcessInfo(pid); ok {
+						info.Source = "fanotify"
+						info.Time = time.Now()
+						recordWriter(path, info)
+					}
+				}
+			}
+			offset += eventLen
+		}
+	}
+}
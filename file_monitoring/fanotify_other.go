@@ -0,0 +1,12 @@
+//go:build !(linux && (amd64 || arm64))
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func startFanotify() error {
+	return fmt.Errorf("fanotify is not supported on %s/%s", runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Duoi file tai lieu hay bi dung de nguy trang file thuc thi (report.pdf.exe)
+var decoyExtensions = map[string]bool{
+	".pdf": true, ".doc": true, ".docx": true, ".xls": true, ".xlsx": true, ".ppt": true, ".pptx": true,
+	".txt": true, ".rtf": true, ".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".mp3": true,
+	".mp4": true, ".zip": true, ".csv": true, ".html": true,
+}
+
+// Duoi file co the thuc thi
+var executableExtensions = map[string]bool{
+	".exe": true, ".scr": true, ".bat": true, ".cmd": true, ".com": true, ".pif": true, ".js": true,
+	".jse": true, ".vbs": true, ".vbe": true, ".wsf": true, ".ps1": true, ".hta": true, ".jar": true,
+	".msi": true, ".lnk": true, ".dll": true, ".cpl": true, ".sh": true, ".elf": true, ".bin": true,
+}
+
+// Ky tu dieu khien huong van ban (bidi) va ky tu vo hinh
+func isBidiOrInvisible(r rune) bool {
+	switch {
+	case r >= 0x202A && r <= 0x202E, r >= 0x2066 && r <= 0x2069:
+		return true
+	case r == 0x200E, r == 0x200F, r == 0x061C:
+		return true
+	case r >= 0x200B && r <= 0x200D, r == 0xFEFF, r == 0x2060:
+		return true
+	}
+	return false
+}
+
+// Byte khong hop le trong UTF-8 (range tra ve U+FFFD voi do dai 1), khac voi ky tu U+FFFD that
+func isInvalidByte(s string, i int) bool {
+	r, size := utf8.DecodeRuneInString(s[i:])
+	return r == utf8.RuneError && size == 1
+}
+
+// Ham analyzeFilename tra ve cac dau hieu ten file danh lua: RTL override, homoglyph, duoi kep...
+func analyzeFilename(name string) []string {
+	var findings []string
+	var bidi, control, invalid []string
+	hasLatin, hasConfusable, hasFullwidth := false, false, false
+	for i, r := range name {
+		switch {
+		case isInvalidByte(name, i):
+			invalid = append(invalid, fmt.Sprintf("0x%02X", name[i]))
+		case isBidiOrInvisible(r):
+			bidi = append(bidi, fmt.Sprintf("U+%04X", r))
+		case !unicode.IsPrint(r):
+			control = append(control, fmt.Sprintf("U+%04X", r))
+		case r >= 0xFF01 && r <= 0xFF5E:
+			hasFullwidth = true
+		case unicode.In(r, unicode.Cyrillic, unicode.Greek, unicode.Armenian):
+			hasConfusable = true
+		case r < 0x80 && unicode.IsLetter(r):
+			hasLatin = true
+		}
+	}
+	if len(bidi) > 0 {
+		findings = append(findings, "contains bidi override or invisible characters "+strings.Join(bidi, " "))
+	}
+	if len(control) > 0 {
+		findings = append(findings, "contains non-printable characters "+strings.Join(control, " "))
+	}
+	if len(invalid) > 0 {
+		findings = append(findings, "contains invalid UTF-8 bytes "+strings.Join(invalid, " "))
+	}
+	if hasLatin && hasConfusable {
+		findings = append(findings, "mixes Latin with Cyrillic/Greek/Armenian letters (possible homoglyphs)")
+	}
+	if hasFullwidth {
+		findings = append(findings, "contains fullwidth characters (possible homoglyphs)")
+	}
+	if trimmed := strings.TrimRight(name, " ."); trimmed != name && trimmed != "" {
+		findings = append(findings, "ends with spaces or dots")
+	}
+
+	// duoi kep: duoi ben trong la tai lieu, duoi ngoai cung co the thuc thi
+	clean := strings.TrimRight(name, " .")
+	outer := strings.ToLower(filepath.Ext(clean))
+	inner := strings.ToLower(filepath.Ext(strings.TrimRight(strings.TrimSuffix(clean, filepath.Ext(clean)), " ")))
+	if executableExtensions[outer] && decoyExtensions[inner] {
+		findings = append(findings, fmt.Sprintf("double extension %s%s", inner, outer))
+	}
+	return findings
+}
+
+// Ham safeName thoat cac ky tu vo hinh/dieu khien trong path de in ra prompt va log an toan.
+// Dau "\" chi duoc thoat ("\\") khi dung ngay truoc mot "\" khac, mot ky tu bi thoat hoac chuoi "u{"/"x{",
+// de ten that khong gia mao duoc ky tu da thoat ma duong dan Windows van giu nguyen
+func safeName(path string) string {
+	trailingStart := len(strings.TrimRight(path, " ")) // dau cach cuoi ten cung bi thoat
+	escaped := func(i int) bool {
+		r, _ := utf8.DecodeRuneInString(path[i:])
+		return isInvalidByte(path, i) || isBidiOrInvisible(r) || !unicode.IsPrint(r) && r != ' ' || r == ' ' && i >= trailingStart
+	}
+	var b strings.Builder
+	for i, r := range path {
+		switch {
+		case isInvalidByte(path, i):
+			fmt.Fprintf(&b, "\\x{%02X}", path[i])
+		case r == ' ' && i >= trailingStart:
+			b.WriteString("\\u{0020}")
+		case escaped(i):
+			fmt.Fprintf(&b, "\\u{%04X}", r)
+		case r == '\\':
+			next := path[i+1:]
+			if strings.HasPrefix(next, "\\") || strings.HasPrefix(next, "u{") || strings.HasPrefix(next, "x{") || (next != "" && escaped(i+1)) {
+				b.WriteString("\\\\")
+			} else {
+				b.WriteByte('\\')
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
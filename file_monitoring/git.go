@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Trang thai file trong working tree so voi cay cua commit HEAD
+const (
+	gitClean     = "clean"
+	gitModified  = "modified"
+	gitStaged    = "staged" // co trong index nhung chua commit
+	gitUntracked = "untracked"
+)
+
+// Mot entry trong .git/index
+type gitIndexEntry struct {
+	Mode    uint32
+	Size    int64
+	ModTime time.Time
+	Hash    string // sha1 cua blob
+}
+
+// Working tree cua mot repository trong folder giam sat
+type gitRepo struct {
+	Root        string
+	GitDir      string
+	Head        string                   // commit hien tai
+	Tree        map[string]gitTreeEntry  // cay cua commit HEAD: duong dan tuyet doi -> blob
+	Entries     map[string]gitIndexEntry // index, chi dung de nhan biet file da stage
+	HeadChanged bool                     // HEAD khac voi lan ghi nhan truoc (deploy/checkout)
+	Approved    bool                     // commit HEAD da duoc duyet, file sach duoc tin cay
+}
+
+var gitRepos map[string]*gitRepo // root -> repo, nap lai moi lan quet
+
+// Cay cua commit HEAD theo repository, chi doc lai khi HEAD doi
+type cachedGitTree struct {
+	Head  string
+	Files map[string]gitTreeEntry
+}
+
+var gitTrees = make(map[string]cachedGitTree)
+
+// sha256 noi dung -> sha1 blob cua git, de khong phai doc lai file khong doi
+var gitBlobCache = make(map[string]string)
+
+// Ham gitCommonDir tra ve thu muc chua objects va refs (khac gitDir voi worktree)
+func gitCommonDir(gitDir string) string {
+	data, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+	commonDir := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(gitDir, commonDir)
+	}
+	return commonDir
+}
+
+// Ham registerGitRepo ghi nhan repository khi gap .git (thu muc, hoac file "gitdir:" cua worktree)
+func registerGitRepo(dotGit string, isDir bool) {
+	root := filepath.Dir(dotGit)
+	gitDir := dotGit
+	if !isDir {
+		data, err := os.ReadFile(dotGit)
+		if err != nil {
+			return
+		}
+		dir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+		if !ok {
+			return
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, dir)
+		}
+		gitDir = dir
+	}
+	repo := &gitRepo{Root: root, GitDir: gitDir, Head: readGitHead(gitDir)}
+	if repo.Head == "" {
+		return // chua co commit: moi file di qua luong duyet binh thuong
+	}
+	cached, ok := gitTrees[root]
+	if !ok || cached.Head != repo.Head {
+		files, err := readCommitTree(gitCommonDir(gitDir), root, repo.Head)
+		if err != nil {
+			fmt.Printf("Warning: Cannot read commit %.12s of git repository %s: %v\n", repo.Head, safeName(root), err)
+			return
+		}
+		cached = cachedGitTree{Head: repo.Head, Files: files}
+		gitTrees[root] = cached
+	}
+	repo.Tree = cached.Files
+	entries, err := readGitIndex(filepath.Join(gitDir, "index"), root)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Warning: Cannot read git index of %s: %v\n", safeName(root), err)
+	}
+	repo.Entries = entries
+	repo.HeadChanged = baseline.GitHeads[root] != repo.Head
+	repo.Approved = !repo.HeadChanged
+	gitRepos[root] = repo
+}
+
+// Ham readGitHead tra ve sha cua commit HEAD (ho tro ref, packed-refs va detached HEAD)
+func readGitHead(gitDir string) string {
+	data, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	head := strings.TrimSpace(string(data))
+	ref, ok := strings.CutPrefix(head, "ref: ")
+	if !ok {
+		return head
+	}
+	// worktree: refs nam trong thu muc chung
+	commonDir := gitCommonDir(gitDir)
+	for _, dir := range []string{gitDir, commonDir} {
+		if data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(ref))); err == nil {
+			return strings.TrimSpace(string(data))
+		}
+	}
+	sha := ""
+	readLines(filepath.Join(commonDir, "packed-refs"), func(line string) {
+		if s, name, ok := strings.Cut(line, " "); ok && name == ref {
+			sha = s
+		}
+	})
+	return sha
+}
+
+// Doc so nguyen kieu "offset varint" dung trong index v4
+func readGitVarint(data []byte) (int, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+	n := 0
+	value := int(data[0] & 0x7f)
+	for data[n]&0x80 != 0 {
+		n++
+		if n >= len(data) {
+			return 0, 0
+		}
+		value = ((value + 1) << 7) | int(data[n]&0x7f)
+	}
+	return value, n + 1
+}
+
+// Ham readGitIndex doc .git/index (version 2, 3, 4), chi lay cac entry stage 0
+func readGitIndex(indexPath, root string) (map[string]gitIndexEntry, error) {
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 || string(data[:4]) != "DIRC" {
+		return nil, fmt.Errorf("invalid index header")
+	}
+	version := binary.BigEndian.Uint32(data[4:8])
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("unsupported index version %d", version)
+	}
+	count := int(binary.BigEndian.Uint32(data[8:12]))
+	entries := make(map[string]gitIndexEntry, count)
+	off, prev := 12, ""
+	for i := 0; i < count; i++ {
+		if off+62 > len(data) {
+			return nil, fmt.Errorf("truncated index")
+		}
+		start := off
+		entry := gitIndexEntry{
+			ModTime: time.Unix(int64(binary.BigEndian.Uint32(data[off+8:])), int64(binary.BigEndian.Uint32(data[off+12:]))),
+			Mode:    binary.BigEndian.Uint32(data[off+24:]),
+			Size:    int64(binary.BigEndian.Uint32(data[off+36:])),
+			Hash:    hex.EncodeToString(data[off+40 : off+60]),
+		}
+		flags := binary.BigEndian.Uint16(data[off+60:])
+		off += 62
+		if version >= 3 && flags&0x4000 != 0 {
+			off += 2 // extended flags
+		}
+		if off > len(data) {
+			return nil, fmt.Errorf("truncated index")
+		}
+		var name string
+		if version == 4 {
+			strip, n := readGitVarint(data[off:])
+			if n == 0 || strip > len(prev) {
+				return nil, fmt.Errorf("corrupt index entry %d", i)
+			}
+			off += n
+			end := bytes.IndexByte(data[off:], 0)
+			if end < 0 {
+				return nil, fmt.Errorf("corrupt index entry %d", i)
+			}
+			name = prev[:len(prev)-strip] + string(data[off:off+end])
+			off += end + 1
+		} else {
+			end := bytes.IndexByte(data[off:], 0)
+			if end < 0 {
+				return nil, fmt.Errorf("corrupt index entry %d", i)
+			}
+			name = string(data[off : off+end])
+			off = start + ((off + end + 1 - start + 7) &^ 7) // entry duoc dem NUL toi boi so cua 8
+		}
+		prev = name
+		if (flags>>12)&3 == 0 {
+			entries[filepath.Join(root, filepath.FromSlash(name))] = entry
+		}
+	}
+	return entries, nil
+}
+
+// Ham gitMetaKind phan loai path nam trong thu muc .git: "hook", "config" (van giam sat vi chay lenh
+// khi dung git) hoac "skip" (object, ref, index do git tu quan ly); "" neu khong nam trong .git
+func gitMetaKind(path string) (kind, root string) {
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	for i, part := range parts[:len(parts)-1] {
+		if part != ".git" {
+			continue
+		}
+		root = filepath.FromSlash(strings.Join(parts[:i], "/"))
+		rest := parts[i+1:]
+		switch {
+		case rest[0] == "hooks":
+			return "hook", root
+		case len(rest) == 1 && rest[0] == "config":
+			return "config", root
+		}
+		return "skip", root
+	}
+	return "", ""
+}
+
+// Tim repository sau nhat co working tree chua path (noi dung .git khong thuoc working tree)
+func gitRepoFor(path string) *gitRepo {
+	var found *gitRepo
+	for root, repo := range gitRepos {
+		if isUnder(path, root) && !isUnder(path, filepath.Join(root, ".git")) && (found == nil || len(root) > len(found.Root)) {
+			found = repo
+		}
+	}
+	return found
+}
+
+// Hash blob cua git: sha1("blob <size>\0" + noi dung)
+func gitBlobHash(content []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Ham blobHash tinh sha1 blob cua file, dung cache theo sha256 noi dung da tinh khi quet
+func blobHash(path string, state FileState) (string, error) {
+	if hash, ok := gitBlobCache[state.Hash]; ok && state.Hash != "" {
+		return hash, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	hash := gitBlobHash(content)
+	if state.Hash != "" {
+		if len(gitBlobCache) > 100000 {
+			gitBlobCache = make(map[string]string)
+		}
+		gitBlobCache[state.Hash] = hash
+	}
+	return hash, nil
+}
+
+// Ham status so sanh file voi cay cua commit HEAD: clean, modified, staged hoac untracked
+func (r *gitRepo) status(path string, state FileState) string {
+	entry, ok := r.Tree[path]
+	if !ok {
+		if _, staged := r.Entries[path]; staged {
+			return gitStaged
+		}
+		return gitUntracked
+	}
+	var hash string
+	var err error
+	if entry.Mode == "120000" {
+		var target string
+		if target, err = os.Readlink(path); err == nil {
+			hash = gitBlobHash([]byte(target))
+		}
+	} else {
+		hash, err = blobHash(path, state)
+	}
+	if err != nil || hash != entry.Hash {
+		return gitModified
+	}
+	return gitClean
+}
+
+// Dong mo ta trang thai git cho prompt
+func gitDetails(path string, state FileState) []string {
+	switch kind, root := gitMetaKind(path); kind {
+	case "hook":
+		if strings.HasSuffix(path, ".sample") {
+			return []string{"Sample git hook of repository " + safeName(root) + ", inactive unless renamed"}
+		}
+		return []string{"Git hook of repository " + safeName(root) + ", runs automatically on git commands"}
+	case "config":
+		return []string{"Git config of repository " + safeName(root) + ", can set hooks, aliases and filters that run commands"}
+	}
+	repo := gitRepoFor(path)
+	if repo == nil {
+		return nil
+	}
+	switch repo.status(path, state) {
+	case gitModified:
+		return []string{"Locally modified file tracked by git repository " + safeName(repo.Root)}
+	case gitStaged:
+		return []string{"File staged but not committed in git repository " + safeName(repo.Root)}
+	case gitUntracked:
+		return []string{"Untracked file in git repository " + safeName(repo.Root)}
+	}
+	return nil
+}
+
+// Ham updateGitHeads: commit thay doi thi hoi mot lan, duoc duyet moi cap nhat hang loat baseline cho
+// cac file sach. Chi ap dung cho repository nam trong thu muc da duyet (repo vua tao co the do ke tan cong)
+func updateGitHeads(current map[string]FileState) bool {
+	roots := make([]string, 0, len(gitRepos))
+	for root, repo := range gitRepos {
+		_, dirApproved := baseline.KnownDirs[root]
+		if repo.HeadChanged && dirApproved && baseline.DeniedGitHeads[root] != repo.Head {
+			roots = append(roots, root)
+		}
+	}
+	sort.Strings(roots)
+
+	changed := false
+	for _, root := range roots {
+		repo := gitRepos[root]
+		var clean []string
+		for path := range repo.Tree {
+			if state, ok := current[path]; ok && repo.status(path, state) == gitClean {
+				clean = append(clean, path)
+			}
+		}
+		old := baseline.GitHeads[root]
+		if old == "" {
+			fmt.Printf("\nDetect git repository %s at commit %.12s (%d clean tracked files)\n", safeName(root), repo.Head, len(clean))
+		} else {
+			fmt.Printf("\nGit repository %s moved from commit %.12s to %.12s (%d clean tracked files)\n", safeName(root), old, repo.Head, len(clean))
+		}
+		changed = true
+		if promptChoice("Approve all clean tracked files of this commit? (y/n): ", "y", "n") != "y" {
+			baseline.DeniedGitHeads[root] = repo.Head
+			fmt.Printf("Commit %.12s is NOT approved, files will be reviewed individually\n", repo.Head)
+			continue
+		}
+		for _, path := range clean {
+			baseline.KnownFiles[path] = true
+			baseline.FileStates[path] = current[path]
+		}
+		baseline.GitHeads[root] = repo.Head
+		delete(baseline.DeniedGitHeads, root)
+		repo.Approved = true
+		fmt.Printf("Approved %d tracked files of git repository %s\n", len(clean), safeName(root))
+	}
+	return changed
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Ham runGit chay lenh git trong repository thu, bo qua test neu khong co git
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_NOSYSTEM=1", "HOME="+dir)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %s: %v", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Ham newTestRepo tao repository co nhieu commit (de git gc sinh delta), symlink va thu muc con
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := t.TempDir()
+	runGit(t, root, "init", "-q")
+	write := func(name, content string) {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var big strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&big, "line %d of a file that changes a little in every commit\n", i)
+	}
+	write("big.txt", big.String())
+	write("sub/dir/script.sh", "#!/bin/sh\necho hi\n")
+	if err := os.Symlink("big.txt", filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, root, "add", "-A")
+	runGit(t, root, "commit", "-q", "-m", "initial")
+	for i := 0; i < 5; i++ {
+		big.WriteString(fmt.Sprintf("appended %d\n", i))
+		write("big.txt", big.String())
+		runGit(t, root, "commit", "-q", "-am", fmt.Sprintf("change %d", i))
+	}
+	return root
+}
+
+// Ham lsTree tra ve cay cua commit theo git ls-tree, key la duong dan tuyet doi
+func lsTree(t *testing.T, root, commit string) map[string]gitTreeEntry {
+	t.Helper()
+	want := make(map[string]gitTreeEntry)
+	for _, line := range strings.Split(runGit(t, root, "ls-tree", "-r", commit), "\n") {
+		meta, name, _ := strings.Cut(line, "\t")
+		f := strings.Fields(meta)
+		want[filepath.Join(root, filepath.FromSlash(name))] = gitTreeEntry{Mode: f[0], Hash: f[2]}
+	}
+	return want
+}
+
+func checkTree(t *testing.T, root, commit string) {
+	t.Helper()
+	got, err := readCommitTree(filepath.Join(root, ".git"), root, commit)
+	if err != nil {
+		t.Fatalf("readCommitTree %s: %v", commit, err)
+	}
+	want := lsTree(t, root, commit)
+	if len(got) != len(want) {
+		t.Fatalf("commit %s: got %d entries, want %d", commit, len(got), len(want))
+	}
+	for path, entry := range want {
+		if got[path] != entry {
+			t.Errorf("commit %s: %s = %+v, want %+v", commit, path, got[path], entry)
+		}
+	}
+}
+
+func TestReadCommitTreePacked(t *testing.T) {
+	root := newTestRepo(t)
+	runGit(t, root, "gc", "-q")
+	// them mot commit sau gc de co ca object loose lan object trong pack
+	if err := os.WriteFile(filepath.Join(root, "loose.txt"), []byte("loose\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, root, "add", "loose.txt")
+	runGit(t, root, "commit", "-q", "-m", "loose")
+
+	packs, _ := filepath.Glob(filepath.Join(root, ".git", "objects", "pack", "*.idx"))
+	if len(packs) == 0 {
+		t.Fatal("git gc did not create a pack")
+	}
+	if !strings.Contains(runGit(t, root, "verify-pack", "-v", packs[0]), "chain length") {
+		t.Fatal("pack has no deltas, test does not cover delta decoding")
+	}
+
+	head := runGit(t, root, "rev-parse", "HEAD")
+	if got := readGitHead(filepath.Join(root, ".git")); got != head {
+		t.Fatalf("readGitHead = %q, want %q", got, head)
+	}
+	for _, commit := range []string{"HEAD", "HEAD~1", "HEAD~3", "HEAD~5"} {
+		checkTree(t, root, runGit(t, root, "rev-parse", commit))
+	}
+}
+
+func TestGitRepoStatus(t *testing.T) {
+	root := newTestRepo(t)
+	runGit(t, root, "gc", "-q")
+	if err := os.WriteFile(filepath.Join(root, "staged.sh"), []byte("evil\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, root, "add", "staged.sh")
+	if err := os.WriteFile(filepath.Join(root, "untracked.txt"), []byte("x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// noi dung doi nhung size va mtime giu nguyen
+	script := filepath.Join(root, "sub", "dir", "script.sh")
+	info, _ := os.Stat(script)
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho no\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(script, info.ModTime(), info.ModTime())
+
+	baseline = FileBaseline{GitHeads: make(map[string]string)}
+	gitRepos = make(map[string]*gitRepo)
+	registerGitRepo(filepath.Join(root, ".git"), true)
+	repo := gitRepos[root]
+	if repo == nil {
+		t.Fatal("repository was not registered")
+	}
+	if repo.Approved || !repo.HeadChanged {
+		t.Fatal("new repository must not be approved before prompting")
+	}
+	want := map[string]string{
+		"big.txt":           gitClean,
+		"link":              gitClean,
+		"sub/dir/script.sh": gitModified,
+		"staged.sh":         gitStaged,
+		"untracked.txt":     gitUntracked,
+	}
+	for name, status := range want {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		info, err := os.Lstat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := repo.status(path, getFileState(path, info, FileState{}, false)); got != status {
+			t.Errorf("%s: status %s, want %s", name, got, status)
+		}
+	}
+}
+
+func TestReadCommitTreeRejectsBadNames(t *testing.T) {
+	root := newTestRepo(t)
+	blob := runGit(t, root, "rev-parse", "HEAD:sub/dir/script.sh")
+	for _, name := range []string{"..", ".git", "."} {
+		cmd := exec.Command("git", "mktree")
+		cmd.Dir = root
+		cmd.Stdin = strings.NewReader("100644 blob " + blob + "\t" + name + "\n")
+		out, err := cmd.Output()
+		if err != nil {
+			t.Logf("git mktree refused %q", name)
+			continue
+		}
+		tree := strings.TrimSpace(string(out))
+		commit := runGit(t, root, "commit-tree", tree, "-m", "bad")
+		if _, err := readCommitTree(filepath.Join(root, ".git"), root, commit); err == nil {
+			t.Errorf("tree entry %q was accepted", name)
+		}
+	}
+}
+
+func TestReadGitIndexV4(t *testing.T) {
+	root := newTestRepo(t)
+	runGit(t, root, "update-index", "--index-version", "4")
+	entries, err := readGitIndex(filepath.Join(root, ".git", "index"), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(runGit(t, root, "ls-files", "-s"), "\n")
+	if len(entries) != len(lines) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(lines))
+	}
+	for _, line := range lines {
+		meta, name, _ := strings.Cut(line, "\t")
+		f := strings.Fields(meta)
+		entry, ok := entries[filepath.Join(root, filepath.FromSlash(name))]
+		if !ok {
+			t.Errorf("missing index entry %s", name)
+			continue
+		}
+		if fmt.Sprintf("%o", entry.Mode) != f[0] || entry.Hash != f[1] {
+			t.Errorf("%s: mode %o hash %s, want %s %s", name, entry.Mode, entry.Hash, f[0], f[1])
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Doc object cua git (loose va packfile) de lay cay thu muc cua commit HEAD
+
+const (
+	gitObjCommit   = 1
+	gitObjTree     = 2
+	gitObjBlob     = 3
+	gitObjTag      = 4
+	gitObjOfsDelta = 6
+	gitObjRefDelta = 7
+)
+
+var gitObjTypes = map[string]int{"commit": gitObjCommit, "tree": gitObjTree, "blob": gitObjBlob, "tag": gitObjTag}
+
+// Mot packfile va file .idx (version 2) cua no
+type gitPack struct {
+	idx   []byte
+	count int
+	file  *os.File
+}
+
+// Kho object cua mot repository, mo trong luc doc cay cua mot commit
+type gitObjects struct {
+	dir   string // thu muc objects
+	packs []*gitPack
+}
+
+// Ham openGitObjects nap cac file .idx trong objects/pack
+func openGitObjects(commonDir string) (*gitObjects, error) {
+	store := &gitObjects{dir: filepath.Join(commonDir, "objects")}
+	idxFiles, _ := filepath.Glob(filepath.Join(store.dir, "pack", "*.idx"))
+	for _, idxPath := range idxFiles {
+		idx, err := os.ReadFile(idxPath)
+		if err != nil {
+			store.close()
+			return nil, err
+		}
+		if len(idx) < 8+1024 || !bytes.Equal(idx[:4], []byte{0xff, 't', 'O', 'c'}) || binary.BigEndian.Uint32(idx[4:8]) != 2 {
+			store.close()
+			return nil, fmt.Errorf("unsupported pack index %s", filepath.Base(idxPath))
+		}
+		f, err := os.Open(strings.TrimSuffix(idxPath, ".idx") + ".pack")
+		if err != nil {
+			store.close()
+			return nil, err
+		}
+		count := int(binary.BigEndian.Uint32(idx[8+255*4:]))
+		if len(idx) < 8+1024+count*28 {
+			f.Close()
+			store.close()
+			return nil, fmt.Errorf("truncated pack index %s", filepath.Base(idxPath))
+		}
+		store.packs = append(store.packs, &gitPack{idx: idx, count: count, file: f})
+	}
+	return store, nil
+}
+
+func (s *gitObjects) close() {
+	for _, p := range s.packs {
+		p.file.Close()
+	}
+}
+
+// Ham find tim offset cua object trong pack qua bang fanout va tim kiem nhi phan
+func (p *gitPack) find(sha []byte) (int64, bool) {
+	fanout := func(i int) int { return int(binary.BigEndian.Uint32(p.idx[8+i*4:])) }
+	lo, hi := 0, fanout(int(sha[0]))
+	if sha[0] > 0 {
+		lo = fanout(int(sha[0]) - 1)
+	}
+	names := 8 + 1024
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch cmp := bytes.Compare(p.idx[names+mid*20:names+mid*20+20], sha); {
+		case cmp == 0:
+			offsets := names + p.count*24
+			off := binary.BigEndian.Uint32(p.idx[offsets+mid*4:])
+			if off&0x80000000 == 0 {
+				return int64(off), true
+			}
+			large := offsets + p.count*4 + int(off&0x7fffffff)*8
+			if large+8 > len(p.idx) {
+				return 0, false
+			}
+			return int64(binary.BigEndian.Uint64(p.idx[large:])), true
+		case cmp < 0:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return 0, false
+}
+
+// Ham inflate giai nen du lieu zlib
+func inflate(r io.Reader) ([]byte, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// Ham read doc object theo sha (hex), tra ve kieu va noi dung
+func (s *gitObjects) read(sha string) (int, []byte, error) {
+	raw, err := hex.DecodeString(sha)
+	if err != nil || len(raw) != 20 {
+		return 0, nil, fmt.Errorf("invalid object id %q", sha)
+	}
+	for _, p := range s.packs {
+		if off, ok := p.find(raw); ok {
+			return s.readPacked(p, off, 0)
+		}
+	}
+	f, err := os.Open(filepath.Join(s.dir, sha[:2], sha[2:]))
+	if err != nil {
+		return 0, nil, fmt.Errorf("object %s not found", sha)
+	}
+	defer f.Close()
+	data, err := inflate(f)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to read object %s: %v", sha, err)
+	}
+	// "<kieu> <kich thuoc>\0<noi dung>"
+	header, body, ok := bytes.Cut(data, []byte{0})
+	kind, _, _ := strings.Cut(string(header), " ")
+	if !ok || gitObjTypes[kind] == 0 {
+		return 0, nil, fmt.Errorf("malformed object %s", sha)
+	}
+	return gitObjTypes[kind], body, nil
+}
+
+// Ham readPacked doc object tai offset trong pack, ap dung delta neu can
+func (s *gitObjects) readPacked(p *gitPack, offset int64, depth int) (int, []byte, error) {
+	if depth > 100 {
+		return 0, nil, fmt.Errorf("delta chain too deep")
+	}
+	r := bufio.NewReader(io.NewSectionReader(p.file, offset, 1<<62))
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	kind := int(b>>4) & 7
+	for b&0x80 != 0 { // kich thuoc dang varint, khong can dung
+		if b, err = r.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+	}
+	var baseKind int
+	var base []byte
+	switch kind {
+	case gitObjOfsDelta:
+		b, err = r.ReadByte()
+		rel := int64(b & 0x7f)
+		for err == nil && b&0x80 != 0 {
+			b, err = r.ReadByte()
+			rel = ((rel + 1) << 7) | int64(b&0x7f)
+		}
+		if err != nil || rel > offset {
+			return 0, nil, fmt.Errorf("corrupt delta at offset %d", offset)
+		}
+		baseKind, base, err = s.readPacked(p, offset-rel, depth+1)
+	case gitObjRefDelta:
+		sha := make([]byte, 20)
+		if _, err = io.ReadFull(r, sha); err == nil {
+			baseKind, base, err = s.read(hex.EncodeToString(sha))
+		}
+	default:
+		data, err := inflate(r)
+		return kind, data, err
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	delta, err := inflate(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	data, err := applyDelta(base, delta)
+	return baseKind, data, err
+}
+
+// Doc so nguyen varint (little-endian, 7 bit) trong delta
+func deltaVarint(delta []byte, pos int) (int, int) {
+	value, shift := 0, 0
+	for pos < len(delta) {
+		b := delta[pos]
+		pos++
+		value |= int(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	return value, pos
+}
+
+// Ham applyDelta dung lai object tu object goc va delta (lenh copy/insert)
+func applyDelta(base, delta []byte) ([]byte, error) {
+	srcSize, pos := deltaVarint(delta, 0)
+	dstSize, pos := deltaVarint(delta, pos)
+	if srcSize != len(base) {
+		return nil, fmt.Errorf("delta base size mismatch")
+	}
+	out := make([]byte, 0, dstSize)
+	for pos < len(delta) {
+		op := delta[pos]
+		pos++
+		if op&0x80 == 0 {
+			// chen op byte tiep theo
+			if op == 0 || pos+int(op) > len(delta) {
+				return nil, fmt.Errorf("corrupt delta")
+			}
+			out = append(out, delta[pos:pos+int(op)]...)
+			pos += int(op)
+			continue
+		}
+		// copy tu object goc: offset 4 byte va size 3 byte, moi byte co mat neu bit tuong ung duoc bat
+		var off, size int
+		for i := 0; i < 7; i++ {
+			if op&(1<<i) == 0 {
+				continue
+			}
+			if pos >= len(delta) {
+				return nil, fmt.Errorf("corrupt delta")
+			}
+			if i < 4 {
+				off |= int(delta[pos]) << (8 * i)
+			} else {
+				size |= int(delta[pos]) << (8 * (i - 4))
+			}
+			pos++
+		}
+		if size == 0 {
+			size = 0x10000
+		}
+		if off+size > len(base) {
+			return nil, fmt.Errorf("corrupt delta")
+		}
+		out = append(out, base[off:off+size]...)
+	}
+	if len(out) != dstSize {
+		return nil, fmt.Errorf("delta result size mismatch")
+	}
+	return out, nil
+}
+
+// Mot file trong cay cua commit
+type gitTreeEntry struct {
+	Mode string
+	Hash string
+}
+
+// Ham readCommitTree doc cay thu muc cua commit: duong dan tuyet doi -> blob
+func readCommitTree(commonDir, root, commit string) (map[string]gitTreeEntry, error) {
+	store, err := openGitObjects(commonDir)
+	if err != nil {
+		return nil, err
+	}
+	defer store.close()
+	kind, data, err := store.read(commit)
+	if err != nil {
+		return nil, err
+	}
+	if kind != gitObjCommit {
+		return nil, fmt.Errorf("HEAD %s is not a commit", commit)
+	}
+	tree, ok := strings.CutPrefix(string(data), "tree ")
+	if !ok || len(tree) < 40 {
+		return nil, fmt.Errorf("malformed commit %s", commit)
+	}
+	files := make(map[string]gitTreeEntry)
+	return files, store.walkTree(tree[:40], root, files)
+}
+
+// Ham walkTree duyet de quy mot tree object
+func (s *gitObjects) walkTree(sha, dir string, files map[string]gitTreeEntry) error {
+	kind, data, err := s.read(sha)
+	if err != nil {
+		return err
+	}
+	if kind != gitObjTree {
+		return fmt.Errorf("object %s is not a tree", sha)
+	}
+	// moi entry: "<mode> <ten>\0<sha 20 byte>"
+	for len(data) > 0 {
+		sp := bytes.IndexByte(data, ' ')
+		nul := bytes.IndexByte(data, 0)
+		if sp < 0 || nul < sp || nul+21 > len(data) {
+			return fmt.Errorf("malformed tree %s", sha)
+		}
+		mode, name := string(data[:sp]), string(data[sp+1:nul])
+		// ten gia mao co the tro ra ngoai repository hoac vao .git (git cung tu choi cac ten nay)
+		if name == "" || name == "." || name == ".." || strings.EqualFold(name, ".git") || strings.ContainsRune(name, '/') ||
+			(os.PathSeparator != '/' && strings.ContainsRune(name, os.PathSeparator)) {
+			return fmt.Errorf("tree %s has invalid entry name %q", sha, name)
+		}
+		hash := hex.EncodeToString(data[nul+1 : nul+21])
+		data = data[nul+21:]
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		switch mode {
+		case "40000":
+			if err := s.walkTree(hash, path, files); err != nil {
+				return err
+			}
+		case "160000":
+			// submodule, co repository rieng
+		default:
+			files[path] = gitTreeEntry{Mode: mode, Hash: hash}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// Nguong so luong/dung luong file moi cho mot folder giam sat (vd: thu muc upload)
+type FolderLimit struct {
+	Folder          string `json:"folder"`
+	IntervalSeconds int    `json:"interval_seconds"` // khoang thoi gian tinh nguong, mac dinh bang chu ky quet
+	MaxNewFiles     int    `json:"max_new_files"`    // so file moi toi da trong interval
+	MaxNewBytes     int64  `json:"max_new_bytes"`    // so bytes them vao toi da trong interval
+	MaxTotalBytes   int64  `json:"max_total_bytes"`  // tong dung luong folder toi da
+	AggregateOnly   bool   `json:"aggregate_only"`   // khong hoi tung file moi, chi canh bao tong hop
+}
+
+// So file va bytes them vao folder trong mot lan quet
+type volumeSample struct {
+	Time  time.Time
+	Files int
+	Bytes int64
+}
+
+var (
+	volumeHistory = make(map[string][]volumeSample) // folder -> cac lan quet trong interval
+	limitExceeded = make(map[string]bool)           // folder + nguong -> dang vuot nguong
+	volumeStarted bool                              // da qua lan quet dau tien sau khi khoi dong
+)
+
+func (l FolderLimit) interval() time.Duration {
+	if l.IntervalSeconds <= 0 {
+		return checkInterval
+	}
+	return time.Duration(l.IntervalSeconds) * time.Second
+}
+
+// Lay nguong cua folder neu co cau hinh
+func folderLimitFor(folder string) (FolderLimit, bool) {
+	for _, limit := range config.FolderLimits {
+		if filepath.Clean(limit.Folder) == filepath.Clean(folder) {
+			return limit, true
+		}
+	}
+	return FolderLimit{}, false
+}
+
+// Ham checkFolderLimits cap nhat bo dem cua tung folder va canh bao tong hop khi vuot nguong
+func checkFolderLimits(events []fileEvent, prev, curr map[string]FileState, now time.Time) {
+	// lan quet dau so voi baseline (chi co file da duyet): moi file co san deu thanh "moi", khong tinh toc do
+	if !volumeStarted {
+		events = nil
+		volumeStarted = true
+	}
+	added := make(map[string]volumeSample)
+	for _, ev := range events {
+		sample := added[ev.Folder]
+		switch ev.Kind {
+		case eventCreate, eventRename:
+			sample.Files++
+			sample.Bytes += curr[ev.Path].Size
+		case eventModify:
+			if grow := curr[ev.Path].Size - prev[ev.Path].Size; grow > 0 {
+				sample.Bytes += grow
+			}
+		}
+		added[ev.Folder] = sample
+	}
+
+	for _, folder := range config.MonitorFolder {
+		limit, ok := folderLimitFor(folder)
+		if !ok {
+			continue
+		}
+		sample := added[folder]
+		sample.Time = now
+		var kept []volumeSample
+		for _, s := range volumeHistory[folder] {
+			if now.Sub(s.Time) < limit.interval() {
+				kept = append(kept, s)
+			}
+		}
+		kept = append(kept, sample)
+		volumeHistory[folder] = kept
+
+		newFiles, newBytes := 0, int64(0)
+		for _, s := range kept {
+			newFiles += s.Files
+			newBytes += s.Bytes
+		}
+		var totalBytes int64
+		for path, state := range curr {
+			if folderOf(path) == folder {
+				totalBytes += state.Size
+			}
+		}
+
+		reportLimit(folder, "new_files", limit.MaxNewFiles > 0 && newFiles > limit.MaxNewFiles,
+			fmt.Sprintf("%d new files in the last %v (limit %d)", newFiles, limit.interval(), limit.MaxNewFiles))
+		reportLimit(folder, "new_bytes", limit.MaxNewBytes > 0 && newBytes > limit.MaxNewBytes,
+			fmt.Sprintf("%d bytes added in the last %v (limit %d)", newBytes, limit.interval(), limit.MaxNewBytes))
+		reportLimit(folder, "total_bytes", limit.MaxTotalBytes > 0 && totalBytes > limit.MaxTotalBytes,
+			fmt.Sprintf("total size %d bytes (limit %d)", totalBytes, limit.MaxTotalBytes))
+	}
+}
+
+// Chi canh bao khi bat dau vuot nguong va khi tro lai binh thuong, tranh lap lai moi lan quet
+func reportLimit(folder, metric string, exceeded bool, message string) {
+	key := folder + "|" + metric
+	switch {
+	case exceeded && !limitExceeded[key]:
+		fmt.Printf("\nALERT: Folder %s exceeded %s threshold: %s\n", folder, metric, message)
+	case !exceeded && limitExceeded[key]:
+		fmt.Printf("Folder %s is back under %s threshold\n", folder, metric)
+	}
+	limitExceeded[key] = exceeded
+}
+
+// Folder chi canh bao tong hop, khong hoi tung file
+func isAggregateOnly(folder string) bool {
+	limit, ok := folderLimitFor(folder)
+	return ok && limit.AggregateOnly
+}
@@ -2,33 +2,89 @@ package main
 
 import (
 	"bufio"
-	"crypto/md5"
+	"crypto/sha256"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
 
 // Config cau hinh giam sat folder
 type MonitorConfig struct {
-	MonitorFolder  []string `json:"monitor_folder"`
-	FileExtensions []string `json:"file_extensions"`
-	IgnoreFiles    []string `json:"ignore_files"`
-	BaseLineFile   string   `json:"baseline_file"`
+	MonitorFolder  []string    `json:"monitor_folder"`
+	FileExtensions []string    `json:"file_extensions"`
+	IgnoreFiles    []string    `json:"ignore_files"`
+	BaseLineFile   string      `json:"baseline_file"`
+	BurstRules     []BurstRule `json:"burst_rules"`
+
+	ArchiveInspection ArchiveConfig `json:"archive_inspection"`
+	ScriptAnalysis    ScriptConfig  `json:"script_analysis"`
+	SecretScan        SecretConfig  `json:"secret_scan"`
+	QuarantineDir     string        `json:"quarantine_dir"`
+	WriterTracking    WriterConfig  `json:"writer_tracking"`
+	SensitiveAccess   AccessConfig  `json:"sensitive_access"`
+	CanaryFiles       []CanaryFile  `json:"canary_files"`
+	CriticalFiles     []string      `json:"critical_files"` // file duoc tu dong khoi phuc khi bi sua/xoa (ho tro glob)
+	StoreDir          string        `json:"store_dir"`      // noi luu ban sao theo hash noi dung
+	Diff              DiffConfig    `json:"diff"`
+	PackageVerify     PackageConfig `json:"package_verify"`
+	BlockedHashes     []string      `json:"blocked_hashes"` // sha256 cua cac file bi cam
+
+	FolderLimits    []FolderLimit `json:"folder_limits"`
+	DuplicatePolicy string        `json:"duplicate_policy"` // report, reuse, reuse_denied
 }
 
 // Trang thai file duoc chap nhan
 type FileBaseline struct {
-	KnownFiles map[string]bool `json:"known_files"` // path -> exist
+	KnownFiles map[string]bool      `json:"known_files"`           // path -> exist
+	FileStates map[string]FileState `json:"file_states,omitempty"` // path -> trang thai file luc duoc duyet
+
+	XattrsRecorded bool `json:"xattrs_recorded,omitempty"` // da ghi nhan xattr cho cac file trong baseline
+
+	KnownDirs    map[string]os.FileMode `json:"known_dirs,omitempty"`    // thu muc -> quyen luc duoc duyet
+	DirsRecorded bool                   `json:"dirs_recorded,omitempty"` // da ghi nhan thu muc trong baseline
+	DeniedDirs   map[string]bool        `json:"denied_dirs,omitempty"`   // thu muc khong rong bi tu choi, khong hoi lai
+
+	DeniedHashes map[string]string `json:"denied_hashes,omitempty"` // hash -> file bi tu choi
+
+	FileWriters map[string]ProcessInfo `json:"file_writers,omitempty"` // path -> process da ghi file luc duoc duyet
+
+	Canaries map[string]CanaryState `json:"canaries,omitempty"` // canary path -> trang thai luc dat
+
+	GitHeads       map[string]string `json:"git_heads,omitempty"`        // thu muc goc repository -> commit da duyet
+	DeniedGitHeads map[string]string `json:"denied_git_heads,omitempty"` // thu muc goc repository -> commit bi tu choi, khong hoi lai
+}
+
+// Trang thai cua mot file tai thoi diem quet
+type FileState struct {
+	Size    int64             `json:"size"`
+	Mode    os.FileMode       `json:"mode"`
+	ModTime time.Time         `json:"mod_time"`
+	Hash    string            `json:"hash,omitempty"`   // sha256
+	Xattrs  map[string]string `json:"xattrs,omitempty"` // extended attributes (security.capability, security.selinux...)
+	UID     int               `json:"uid,omitempty"`
+	GID     int               `json:"gid,omitempty"`
 }
 
 var (
 	config   MonitorConfig
 	baseline FileBaseline
+	lastScan map[string]FileState // trang thai file o lan quet truoc, dung de sinh su kien
 )
 
+// Chu ky checkFiles()
+var checkInterval = 1 * time.Minute
+
+// File da duyet co thay doi bi tu choi nhung chua khoi phuc duoc: baseline giu ban da duyet
+var rejectedChanges = make(map[string]bool)
+
+// Dung chung mot scanner cho moi prompt de khong mat du lieu da doc vao buffer
+var inputScanner = bufio.NewScanner(os.Stdin)
+
 // Ham loadConfig su dung de nap cau hinh config phuc vu cho monitor
 func loadConfig(configPath string) error {
 	file, err := os.ReadFile(configPath)
@@ -45,7 +101,15 @@ func loadConfig(configPath string) error {
 func loadBaseline() error {
 	if _, err := os.Stat(config.BaseLineFile); os.IsNotExist(err) {
 		baseline = FileBaseline{
-			KnownFiles: make(map[string]bool),
+			KnownFiles:     make(map[string]bool),
+			FileStates:     make(map[string]FileState),
+			KnownDirs:      make(map[string]os.FileMode),
+			DeniedDirs:     make(map[string]bool),
+			DeniedHashes:   make(map[string]string),
+			FileWriters:    make(map[string]ProcessInfo),
+			Canaries:       make(map[string]CanaryState),
+			GitHeads:       make(map[string]string),
+			DeniedGitHeads: make(map[string]string),
 		}
 		return nil
 	}
@@ -56,6 +120,34 @@ func loadBaseline() error {
 	if err := json.Unmarshal(file, &baseline); err != nil {
 		return fmt.Errorf("unable to parse baseline file: %v", err)
 	}
+	// baseline cu co the chua co cac truong moi
+	if baseline.KnownFiles == nil {
+		baseline.KnownFiles = make(map[string]bool)
+	}
+	if baseline.FileStates == nil {
+		baseline.FileStates = make(map[string]FileState)
+	}
+	if baseline.KnownDirs == nil {
+		baseline.KnownDirs = make(map[string]os.FileMode)
+	}
+	if baseline.DeniedDirs == nil {
+		baseline.DeniedDirs = make(map[string]bool)
+	}
+	if baseline.DeniedHashes == nil {
+		baseline.DeniedHashes = make(map[string]string)
+	}
+	if baseline.FileWriters == nil {
+		baseline.FileWriters = make(map[string]ProcessInfo)
+	}
+	if baseline.Canaries == nil {
+		baseline.Canaries = make(map[string]CanaryState)
+	}
+	if baseline.GitHeads == nil {
+		baseline.GitHeads = make(map[string]string)
+	}
+	if baseline.DeniedGitHeads == nil {
+		baseline.DeniedGitHeads = make(map[string]string)
+	}
 	return nil
 }
 
@@ -76,7 +168,107 @@ func getFileHash(filePath string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("unable to read file: %v", err)
 	}
-	return fmt.Sprintf("%x", md5.Sum(file)), nil
+	return fmt.Sprintf("%x", sha256.Sum256(file)), nil
+}
+
+// Ham getFileState lay trang thai hien tai cua file, chi tinh lai hash khi size hoac mtime thay doi
+func getFileState(path string, info os.FileInfo, prev FileState, hasPrev bool) FileState {
+	state := FileState{Size: info.Size(), Mode: info.Mode(), ModTime: info.ModTime()}
+	state.UID, state.GID, _ = fileOwner(info)
+	xattrs, err := readXattrs(path)
+	if err != nil {
+		fmt.Printf("Warning: Cannot read extended attributes of %s: %v\n", safeName(path), err)
+	}
+	state.Xattrs = xattrs
+	if hasPrev && prev.Hash != "" && prev.Size == state.Size && prev.ModTime.Equal(state.ModTime) {
+		state.Hash = prev.Hash
+		return state
+	}
+	hash, err := getFileHash(path)
+	if err != nil {
+		fmt.Printf("Warning: Cannot hash %s: %v\n", safeName(path), err)
+	}
+	state.Hash = hash
+	return state
+}
+
+func sameFileState(a, b FileState) bool {
+	return a.Size == b.Size && a.Mode == b.Mode && a.ModTime.Equal(b.ModTime) && a.Hash == b.Hash &&
+		a.UID == b.UID && a.GID == b.GID && sameXattrs(a.Xattrs, b.Xattrs)
+}
+
+// Tim folder giam sat chua path
+func folderOf(path string) string {
+	for _, folder := range config.MonitorFolder {
+		if path == folder || strings.HasPrefix(path, strings.TrimSuffix(folder, string(os.PathSeparator))+string(os.PathSeparator)) {
+			return folder
+		}
+	}
+	return ""
+}
+
+// Ham diffSnapshots so sanh hai lan quet va sinh ra cac su kien create/modify/delete/rename
+// Ham eventTime tra ve thoi diem su kien: mtime chi duoc dung khi nam trong chu ky quet vua qua,
+// vi rename giu nguyen mtime cu va mtime co the bi dat lui (touch -d)
+func eventTime(mtime, now time.Time) time.Time {
+	if mtime.After(now.Add(-checkInterval)) && !mtime.After(now) {
+		return mtime
+	}
+	return now
+}
+
+func diffSnapshots(prev, curr map[string]FileState, now time.Time) []fileEvent {
+	var events []fileEvent
+	var created, deleted []string
+	for path, state := range curr {
+		old, ok := prev[path]
+		if !ok {
+			// file da duyet tu baseline cu (chua co trang thai) thi khong tinh la file moi
+			if _, known := baseline.KnownFiles[path]; known {
+				continue
+			}
+			created = append(created, path)
+			continue
+		}
+		if old.Hash != state.Hash || old.Size != state.Size {
+			events = append(events, fileEvent{Kind: eventModify, Path: path, Folder: folderOf(path), Time: eventTime(state.ModTime, now)})
+		}
+	}
+	for path := range prev {
+		if _, ok := curr[path]; !ok {
+			deleted = append(deleted, path)
+		}
+	}
+	sort.Strings(created)
+	sort.Strings(deleted)
+
+	// ghep cap delete + create thanh rename: cung noi dung, hoac ten moi = ten cu + duoi moi (vd: a.doc -> a.doc.locked)
+	matched := make(map[string]bool)
+	for _, path := range created {
+		state := curr[path]
+		oldPath := ""
+		for _, d := range deleted {
+			if matched[d] {
+				continue
+			}
+			if (state.Hash != "" && prev[d].Hash == state.Hash) || strings.HasPrefix(path, d+".") {
+				oldPath = d
+				break
+			}
+		}
+		if oldPath != "" {
+			matched[oldPath] = true
+			events = append(events, fileEvent{Kind: eventRename, Path: path, OldPath: oldPath, Folder: folderOf(path), Time: eventTime(state.ModTime, now)})
+		} else {
+			events = append(events, fileEvent{Kind: eventCreate, Path: path, Folder: folderOf(path), Time: eventTime(state.ModTime, now)})
+		}
+	}
+	for _, path := range deleted {
+		if !matched[path] {
+			events = append(events, fileEvent{Kind: eventDelete, Path: path, Folder: folderOf(path), Time: now})
+		}
+	}
+	return events
 }
 
 //func promptApproval(path string) bool {
@@ -90,19 +282,38 @@ func getFileHash(filePath string) (string, error) {
 //	return strings.EqualFold(response, "y")
 //}
 
-// Cho phep port cho process tuong ung hoac khong
-func promptApproval(path string) bool {
-	fmt.Printf("\nDetect new files %s\n", path)
-	scanner := bufio.NewScanner(os.Stdin)
+// Hoi nguoi dung cho den khi nhap mot trong cac lua chon
+func promptChoice(question string, choices ...string) string {
+	for {
+		fmt.Print(question)
+		if !inputScanner.Scan() {
+			fmt.Println("Error reading input.")
+			return ""
+		}
+		response := strings.ToLower(strings.TrimSpace(inputScanner.Text()))
+		for _, c := range choices {
+			if response == c {
+				return c
+			}
+		}
+		fmt.Printf("Invalid input. Please enter one of: %s.\n", strings.Join(choices, ", "))
+	}
+}
 
+// Cho phep port cho process tuong ung hoac khong
+func promptApproval(path string, details ...string) bool {
+	fmt.Printf("\nDetect new files %s\n", safeName(path))
+	for _, line := range details {
+		fmt.Println(line)
+	}
 	for {
 		fmt.Print("Approval? (y/n): ")
-		if !scanner.Scan() {
+		if !inputScanner.Scan() {
 			// Xử lý nếu không đọc được input (EOF hoặc lỗi)
 			fmt.Println("Error reading input.")
 			return false
 		}
-		response := strings.TrimSpace(scanner.Text())
+		response := strings.TrimSpace(inputScanner.Text())
 		switch strings.ToLower(response) {
 		case "y":
 			return true
@@ -114,15 +325,63 @@ func promptApproval(path string) bool {
 	}
 }
 
+// Ham metadataDetails kiem tra ten file, hash va capabilities (khong doc noi dung file)
+func metadataDetails(path string, state FileState) []string {
+	var details []string
+	if findings := analyzeFilename(filepath.Base(path)); len(findings) > 0 {
+		fmt.Printf("ALERT: Deceptive file name %s: %s\n", safeName(path), strings.Join(findings, "; "))
+		for _, f := range findings {
+			details = append(details, "File name "+f)
+		}
+	}
+	if isBlockedHash(state.Hash) {
+		fmt.Printf("ALERT: File %s matches a blocked hash\n", safeName(path))
+		details = append(details, "Matches blocked hash "+state.Hash)
+	}
+	if caps := state.Xattrs[xattrCapability]; caps != "" {
+		fmt.Printf("ALERT: New file %s has file capabilities: %s\n", safeName(path), describeCapabilities(caps))
+		details = append(details, "File capabilities: "+describeCapabilities(caps))
+	}
+	return details
+}
+
+// Ham newFileDetails thu thap thong tin bo sung ve file moi de hien thi trong prompt
+func newFileDetails(path string, state FileState) []string {
+	details := metadataDetails(path, state)
+	if config.ScriptAnalysis.Enabled {
+		details = append(details, scriptDetails(path)...)
+	}
+	if config.ArchiveInspection.Enabled {
+		report, err := inspectArchive(path)
+		if err != nil {
+			fmt.Printf("Warning: Cannot inspect archive %s: %v\n", safeName(path), err)
+		}
+		if report != nil {
+			details = append(details, report.details()...)
+		}
+	}
+	return details
+}
+
 func checkFiles() {
 	fmt.Printf("\n Checking files...\n")
 	newFilesFound := false
-	//detectedFiles := make([]string, 0) // luu danh sach file moi phat hien
+	now := time.Now()
+	if lastScan == nil {
+		lastScan = make(map[string]FileState)
+		for path, state := range baseline.FileStates {
+			lastScan[path] = state
+		}
+	}
+	current := make(map[string]FileState)       // trang thai cac file trong lan quet nay
+	currentDirs := make(map[string]os.FileMode) // cac thu muc trong lan quet nay
+	var detectedFiles []string                  // luu danh sach file moi phat hien
+	gitRepos = make(map[string]*gitRepo)
 	for _, folder := range config.MonitorFolder { //lap qua folder can giam sat
 		filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
 			if err != nil {
 				//return err
-				fmt.Printf("Warning: Cannot access %s: %v\n", path, err)
+				fmt.Printf("Warning: Cannot access %s: %v\n", safeName(path), err)
 				return nil
 			}
 
@@ -133,6 +392,18 @@ func checkFiles() {
 						return filepath.SkipDir
 					}
 				}
+				// repository git: doc HEAD/index, trong .git chi giam sat hooks va config
+				if info.Name() == ".git" {
+					registerGitRepo(path, true)
+					return nil
+				}
+				if kind, _ := gitMetaKind(path); kind != "" {
+					if kind != "hook" {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				currentDirs[path] = info.Mode()
 				return nil
 			}
 
@@ -143,76 +414,310 @@ func checkFiles() {
 			//	}
 			//}
 
+			// canary khong di qua luong duyet, va khong duoc doc de tinh hash
+			if isCanary(path) {
+				return nil
+			}
+			if info.Name() == ".git" {
+				registerGitRepo(path, false) // worktree
+				return nil
+			}
+			if kind, _ := gitMetaKind(path); kind == "skip" {
+				return nil
+			}
+
+			prev, hasPrev := lastScan[path]
+			if !hasPrev {
+				prev, hasPrev = baseline.FileStates[path]
+			}
+			current[path] = getFileState(path, info, prev, hasPrev)
+
 			// Kiem tra neu khong co su khac gi so voi baseline truoc, bo qua
 			if _, exist := baseline.KnownFiles[path]; exist {
 				return nil
 			}
+			detectedFiles = append(detectedFiles, path)
+			return nil
 
-			// Kiem tra extension
-			if len(config.FileExtensions) > 0 {
-				ext := filepath.Ext(path)
-				valiExt := false
-				for _, e := range config.FileExtensions {
-					if strings.EqualFold(ext, e) {
-						valiExt = true
-						break
-					}
+		})
+	}
+
+	checkCanaries()
+	if len(config.PackageVerify.Folders) > 0 {
+		loadPackageDB()
+	}
+	events := diffSnapshots(lastScan, current, now)
+	incidents := detectBursts(events, now)
+	checkFolderLimits(events, lastScan, current, now)
+
+	baselineChanged := checkDirectories(currentDirs, current, incidents)
+	checkRejectedChanges(current, events)
+	// commit moi cua repository da duyet: hoi mot lan truoc khi cap nhat hang loat
+	if updateGitHeads(current) {
+		baselineChanged = true
+	}
+	for _, ev := range events {
+		if !baseline.KnownFiles[ev.Path] || incidents[ev.Folder] {
+			continue
+		}
+		// file sach sau khi doi commit duoc cap nhat hang loat, khong bao tung file
+		if repo := gitRepoFor(ev.Path); repo != nil && repo.HeadChanged && repo.Approved && ev.Kind == eventModify && repo.status(ev.Path, current[ev.Path]) == gitClean {
+			continue
+		}
+		switch ev.Kind {
+		case eventModify:
+			fmt.Printf("Warning: Approved file modified: %s\n", safeName(ev.Path))
+			if config.WriterTracking.Enabled {
+				if writer, ok := lookupWriter(ev.Path); ok {
+					fmt.Printf("Modified by: %s\n", writer)
 				}
-				if !valiExt {
-
-					fmt.Printf("Warning: File %s not found at file_extensions in %s\n", ext, path)
-					//return nil
-					if promptApproval(path) {
-						baseline.KnownFiles[path] = true
-						if err := saveBaseline(); err != nil {
-							fmt.Printf("Unable to save baseline file: %v\n", err)
-						} else {
-							fmt.Printf("Approved file %s not found at file_extensions and saved baseline file: %s\n", ext, path)
-						}
-					} else {
-						err := os.Remove(path)
-						if err != nil {
-							fmt.Printf("Unable to remove %s: %v\n", path, err)
-						} else {
-							fmt.Printf("Removed file %s not found at file_extensions: %s\n", ext, path)
-						}
-					}
+			}
+			if config.SecretScan.Enabled {
+				details, quarantined := checkSecrets(ev.Path)
+				if quarantined {
+					delete(current, ev.Path)
+					continue
+				}
+				for _, line := range details {
+					fmt.Println(line)
 				}
 			}
+			if isPackageFolder(ev.Path) {
+				reportPackageModification(ev.Path)
+			}
+			// file critical se duoc khoi phuc tu dong, khong can hoi
+			if config.Diff.Enabled && !isCritical(ev.Path) {
+				reviewModification(ev.Path, current)
+			}
+		case eventDelete:
+			fmt.Printf("Warning: Approved file deleted: %s\n", safeName(ev.Path))
+		}
+	}
+	restoreCriticalFiles(current)
+	// cap nhat trang thai cho cac file da duyet (ke ca baseline cu chua co trang thai)
+	for path, state := range current {
+		if !baseline.KnownFiles[path] || rejectedChanges[path] {
+			continue
+		}
+		old, ok := baseline.FileStates[path]
+		if ok && baseline.XattrsRecorded && !sameXattrs(old.Xattrs, state.Xattrs) {
+			checkXattrChanges(path, old.Xattrs, state.Xattrs)
+		}
+		if !ok || !sameFileState(old, state) {
+			baseline.FileStates[path] = state
+			baselineChanged = true
+		}
+	}
+	// baseline cu chua co xattr: lan quet dau chi ghi nhan, khong canh bao
+	if !baseline.XattrsRecorded {
+		baseline.XattrsRecorded = true
+		baselineChanged = true
+	}
+	if baselineChanged {
+		if err := saveBaseline(); err != nil {
+			fmt.Printf("Unable to save baseline file: %v\n", err)
+		}
+	}
 
-			// Kiem tra file moi
+	heldFolders := make(map[string]bool)
+	aggregated := make(map[string]int) // folder -> so file moi ghi nhan khong qua prompt
+	var toReview []string              // file moi can hoi nguoi dung
+	for _, path := range detectedFiles {
+		if baseline.KnownFiles[path] {
+			continue // da duoc duyet cung ca cay thu muc
+		}
+		// trong luc co su co ransomware, khong hoi tung file ma giu lai de xu ly sau
+		folder := folderOf(path)
+		if incidents[folder] {
+			if !heldFolders[folder] {
+				heldFolders[folder] = true
+				fmt.Printf("New files in %s are held for review during the active incident\n", folder)
+			}
 			newFilesFound = true
-			if promptApproval(path) {
+			continue
+		}
+		// file trong repository git khop voi commit da duyet: duyet luon
+		if repo := gitRepoFor(path); repo != nil && repo.Approved && repo.status(path, current[path]) == gitClean {
+			baseline.KnownFiles[path] = true
+			baseline.FileStates[path] = current[path]
+			baselineChanged = true
+			continue
+		}
+		// folder chi theo doi nguong: ghi nhan vao baseline, khong hoi tung file
+		if isAggregateOnly(folder) {
+			baseline.KnownFiles[path] = true
+			baseline.FileStates[path] = current[path]
+			aggregated[folder]++
+			newFilesFound = true
+			continue
+		}
+		toReview = append(toReview, path)
+	}
+
+	// cung mot noi dung xuat hien o nhieu noi chi hoi mot lan
+	hashIndex := buildHashIndex()
+	duplicateGroups, duplicateCopies := groupDuplicates(toReview, current)
+	for _, path := range toReview {
+		if duplicateCopies[path] {
+			continue // duoc xu ly cung file dai dien
+		}
+		copies := duplicateGroups[path]
+		state := current[path]
+		newFilesFound = true
+
+		if decision, ref := reusedDecision(state.Hash, hashIndex); decision != "" {
+			if decision == "approve" {
+				baseline.KnownFiles[path] = true
+				baseline.FileStates[path] = state
+				fmt.Printf("Auto-approved %s: same content as approved file %s\n", safeName(path), safeName(ref))
+				applyToCopies(copies, true, current)
+			} else {
+				if err := os.Remove(path); err != nil {
+					fmt.Printf("Unable to remove %s: %v\n", safeName(path), err)
+				} else {
+					delete(current, path)
+					fmt.Printf("Auto-removed %s: same content as denied file %s\n", safeName(path), safeName(ref))
+				}
+				applyToCopies(copies, false, current)
+			}
+			if err := saveBaseline(); err != nil {
+				fmt.Printf("Unable to save baseline file: %v\n", err)
+			}
+			continue
+		}
+
+		details := newFileDetails(path, state)
+		details = append(details, duplicateDetails(state.Hash, hashIndex, copies)...)
+		details = append(details, gitDetails(path, state)...)
+		if isPackageFolder(path) {
+			result := verifyPackageFile(path)
+			if result.Matches && config.PackageVerify.AutoApprove {
 				baseline.KnownFiles[path] = true
+				baseline.FileStates[path] = state
+				applyToCopies(copies, true, current)
+				fmt.Printf("Auto-approved %s: %s\n", safeName(path), result.Message)
 				if err := saveBaseline(); err != nil {
 					fmt.Printf("Unable to save baseline file: %v\n", err)
-				} else {
-					fmt.Printf("Approved and saved baseline file: %s\n", path)
 				}
-			} else {
-				if err := os.Remove(path); err != nil {
-					fmt.Printf("Unable to remove %s: %v\n", path, err)
+				continue
+			}
+			if result.Owned && !result.Matches && !result.Conffile {
+				fmt.Printf("ALERT: File %s %s\n", safeName(path), result.Message)
+			} else if !result.Owned {
+				fmt.Printf("Warning: File %s %s\n", safeName(path), result.Message)
+			}
+			details = append(details, "Package: "+result.Message)
+		}
+		var writer ProcessInfo
+		if config.WriterTracking.Enabled {
+			var lines []string
+			writer, lines = writerDetails(path)
+			details = append(details, lines...)
+		}
+		if config.SecretScan.Enabled {
+			secrets, quarantined := checkSecrets(path)
+			if quarantined {
+				delete(current, path)
+				continue
+			}
+			details = append(details, secrets...)
+		}
+
+		// Kiem tra extension
+		if len(config.FileExtensions) > 0 {
+			ext := filepath.Ext(path)
+			valiExt := false
+			for _, e := range config.FileExtensions {
+				if strings.EqualFold(ext, e) {
+					valiExt = true
+					break
+				}
+			}
+			if !valiExt {
+
+				fmt.Printf("Warning: File %s not found at file_extensions in %s\n", safeName(ext), safeName(path))
+				//return nil
+				if promptApproval(path, details...) {
+					baseline.KnownFiles[path] = true
+					baseline.FileStates[path] = state
+					if err := saveBaseline(); err != nil {
+						fmt.Printf("Unable to save baseline file: %v\n", err)
+					} else {
+						fmt.Printf("Approved file %s not found at file_extensions and saved baseline file: %s\n", safeName(ext), safeName(path))
+					}
 				} else {
-					fmt.Printf("Removed file: %s\n", path)
+					recordDenied(path, state)
+					err := os.Remove(path)
+					if err != nil {
+						fmt.Printf("Unable to remove %s: %v\n", safeName(path), err)
+					} else {
+						delete(current, path)
+						fmt.Printf("Removed file %s not found at file_extensions: %s\n", safeName(ext), safeName(path))
+					}
 				}
 			}
-			return nil
+		}
 
-		})
+		// Kiem tra file moi
+		if promptApproval(path, details...) {
+			baseline.KnownFiles[path] = true
+			baseline.FileStates[path] = state
+			if writer.PID != 0 {
+				baseline.FileWriters[path] = writer
+			}
+			applyToCopies(copies, true, current)
+			if err := saveBaseline(); err != nil {
+				fmt.Printf("Unable to save baseline file: %v\n", err)
+			} else {
+				fmt.Printf("Approved and saved baseline file: %s\n", safeName(path))
+			}
+		} else {
+			recordDenied(path, state)
+			if err := os.Remove(path); err != nil {
+				fmt.Printf("Unable to remove %s: %v\n", safeName(path), err)
+			} else {
+				delete(current, path)
+				fmt.Printf("Removed file: %s\n", safeName(path))
+			}
+			applyToCopies(copies, false, current)
+			if err := saveBaseline(); err != nil {
+				fmt.Printf("Unable to save baseline file: %v\n", err)
+			}
+		}
+	}
+	for folder, count := range aggregated {
+		fmt.Printf("Recorded %d new files in %s without review (aggregate_only)\n", count, folder)
 	}
+	if len(aggregated) > 0 {
+		if err := saveBaseline(); err != nil {
+			fmt.Printf("Unable to save baseline file: %v\n", err)
+		}
+	}
+	storeApprovedCopies(current)
+	lastScan = current
+	pruneWriters(now)
 	if !newFilesFound {
 		fmt.Printf("\n No new files found.\n")
 	}
 }
 
 func main() {
+	// --root: thu muc goc cua image da mount, --tar: file tar cua filesystem (quet offline, chi doc)
+	offlineRoot := flag.String("root", "", "scan a mounted image under this prefix (read-only, one-shot)")
+	offlineTar := flag.String("tar", "", "scan a tar archive of a filesystem (read-only, one-shot)")
+	flag.Parse()
+
 	//Dam bao nap config.json
-	if len(os.Args) < 2 {
-		fmt.Println("Usage config_file") //đảm bảo len(os.Args) = 1, điều kiện này đúng, người dùng cần cung cấp file đường dẫn config
+	if flag.NArg() < 1 {
+		fmt.Println("Usage [--root dir | --tar file] config_file")
+		os.Exit(1)
+	}
+	if *offlineRoot != "" && *offlineTar != "" {
+		fmt.Println("Only one of --root and --tar can be used")
 		os.Exit(1)
 	}
 
-	if err := loadConfig(os.Args[1]); err != nil {
+	if err := loadConfig(flag.Arg(0)); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -222,20 +727,47 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *offlineRoot != "" || *offlineTar != "" {
+		findings, err := offlineScan(*offlineRoot, *offlineTar)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if findings > 0 {
+			os.Exit(2)
+		}
+		return
+	}
+
 	fmt.Print("\n File monitoring program has started \n")
 	fmt.Printf("\n Monitoring %d folder \n", len(config.MonitorFolder))
+	setupCanaries()
+	if config.WriterTracking.Enabled && config.WriterTracking.UseFanotify {
+		if err := startFanotify(); err != nil {
+			fmt.Printf("Warning: %v, falling back to scanning /proc/*/fd\n", err)
+		}
+	}
 	checkFiles()
 
 	// Tao ticker, dat thoi gian checkFiles()
-	checkInterval := 1 * time.Minute
-
 	ticker := time.NewTicker(checkInterval)
 	defer ticker.Stop()
 
+	// Kiem tra file nhay cam dang mo theo chu ky rieng
+	var auditC <-chan time.Time
+	if config.SensitiveAccess.Enabled {
+		auditTicker := time.NewTicker(config.SensitiveAccess.interval())
+		defer auditTicker.Stop()
+		auditC = auditTicker.C
+		auditSensitiveAccess()
+	}
+
 	for {
 		select {
 		case <-ticker.C:
 			checkFiles()
+		case <-auditC:
+			auditSensitiveAccess()
 		}
 	}
 
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Quet offline (image da mount hoac file tar) va so sanh voi baseline.
+// Che do chi doc: khong xoa, khong quarantine, khong khoi phuc, khong hoi va khong luu baseline.
+
+// Ham offlinePath doi duong dan trong baseline thanh duong dan duoi root
+func offlinePath(root, path string) string {
+	return filepath.Join(root, path)
+}
+
+// Ham skipOfflineDir: cung quy tac bo qua thu muc nhu khi quet that
+func skipOfflineDir(name string) bool {
+	for _, ignore := range config.IgnoreFiles {
+		if strings.Contains(name, ignore) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ham scanOfflineRoot duyet cac folder giam sat ben duoi root, key la duong dan goc tren host
+func scanOfflineRoot(root string) map[string]FileState {
+	current := make(map[string]FileState)
+	for _, folder := range config.MonitorFolder {
+		filepath.Walk(offlinePath(root, folder), func(scanPath string, info os.FileInfo, err error) error {
+			if err != nil {
+				fmt.Printf("Warning: Cannot access %s: %v\n", safeName(scanPath), err)
+				return nil
+			}
+			if info.IsDir() {
+				if skipOfflineDir(info.Name()) {
+					return filepath.SkipDir
+				}
+				if kind, _ := gitMetaKind(scanPath); kind != "" && kind != "hook" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			hostPath := filepath.Join(string(os.PathSeparator), strings.TrimPrefix(scanPath, filepath.Clean(root)))
+			if kind, _ := gitMetaKind(hostPath); isCanary(hostPath) || kind == "skip" {
+				return nil
+			}
+			// symlink tuyet doi se tro ra host, khong doc noi dung
+			if info.Mode()&os.ModeSymlink != 0 {
+				state := FileState{Size: info.Size(), Mode: info.Mode(), ModTime: info.ModTime()}
+				state.UID, state.GID, _ = fileOwner(info)
+				current[hostPath] = state
+				return nil
+			}
+			current[hostPath] = getFileState(scanPath, info, FileState{}, false)
+			return nil
+		})
+	}
+	return current
+}
+
+// Ham openTar mo file tar, tu giai nen neu la gzip
+func openTar(tarPath string) (*tar.Reader, io.Closer, error) {
+	f, err := os.Open(tarPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to open tar file: %v", err)
+	}
+	br := bufio.NewReader(f)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("unable to read gzip stream: %v", err)
+		}
+		return tar.NewReader(gz), f, nil
+	}
+	return tar.NewReader(br), f, nil
+}
+
+// Ham scanOfflineTar doc truc tiep cac member cua tar nam trong folder giam sat
+func scanOfflineTar(tarPath string) (map[string]FileState, error) {
+	tr, closer, err := openTar(tarPath)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+	current := make(map[string]FileState)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return current, fmt.Errorf("unable to read tar file: %v", err)
+		}
+		name := path.Clean("/" + hdr.Name)
+		hostPath := filepath.FromSlash(name)
+		if kind, _ := gitMetaKind(hostPath); folderOf(hostPath) == "" || isCanary(hostPath) || kind == "skip" {
+			continue
+		}
+		skip := false
+		for _, dir := range strings.Split(path.Dir(name), "/") {
+			if dir != "" && skipOfflineDir(dir) {
+				skip = true
+				break
+			}
+		}
+		if skip {
+			continue
+		}
+		state := FileState{Mode: hdr.FileInfo().Mode(), ModTime: hdr.ModTime, UID: hdr.Uid, GID: hdr.Gid}
+		for key, value := range hdr.PAXRecords {
+			if attr, ok := strings.CutPrefix(key, "SCHILY.xattr."); ok {
+				if state.Xattrs == nil {
+					state.Xattrs = make(map[string]string)
+				}
+				state.Xattrs[attr] = encodeXattr([]byte(value))
+			}
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			h := sha256.New()
+			n, err := io.Copy(h, tr)
+			if err != nil {
+				return current, fmt.Errorf("unable to read %s from tar file: %v", hdr.Name, err)
+			}
+			state.Size = n
+			state.Hash = fmt.Sprintf("%x", h.Sum(nil))
+		case tar.TypeLink:
+			// hard link: dung noi dung cua member da gap truoc do
+			target := current[filepath.FromSlash(path.Clean("/"+hdr.Linkname))]
+			state.Mode, state.Size, state.Hash = target.Mode, target.Size, target.Hash
+		case tar.TypeSymlink:
+			state.Size = int64(len(hdr.Linkname))
+		default:
+			continue
+		}
+		current[hostPath] = state
+	}
+	return current, nil
+}
+
+// Ham offlineChanges liet ke cac thay doi so voi trang thai trong baseline
+func offlineChanges(old, cur FileState, compareXattrs bool) []string {
+	var changes []string
+	if cur.Mode&os.ModeSymlink == 0 && old.Hash != "" && cur.Hash != old.Hash {
+		changes = append(changes, fmt.Sprintf("content changed (sha256 %.12s -> %.12s)", old.Hash, cur.Hash))
+	}
+	if old.Size != cur.Size {
+		changes = append(changes, fmt.Sprintf("size %d -> %d", old.Size, cur.Size))
+	}
+	if old.Mode != cur.Mode {
+		changes = append(changes, fmt.Sprintf("mode %v -> %v", old.Mode, cur.Mode))
+	}
+	if old.UID != cur.UID || old.GID != cur.GID {
+		changes = append(changes, fmt.Sprintf("owner %d:%d -> %d:%d", old.UID, old.GID, cur.UID, cur.GID))
+	}
+	// tar chi luu mtime den giay
+	if !old.ModTime.Truncate(time.Second).Equal(cur.ModTime.Truncate(time.Second)) {
+		changes = append(changes, fmt.Sprintf("mtime %s -> %s", old.ModTime.Format(time.RFC3339), cur.ModTime.Format(time.RFC3339)))
+	}
+	if compareXattrs && !sameXattrs(old.Xattrs, cur.Xattrs) {
+		changes = append(changes, "extended attributes changed")
+	}
+	return changes
+}
+
+// Ham offlineScan quet mot lan va in bao cao, tra ve so phat hien
+func offlineScan(root, tarPath string) (int, error) {
+	var current map[string]FileState
+	source := root
+	if tarPath != "" {
+		source = tarPath
+		var err error
+		if current, err = scanOfflineTar(tarPath); err != nil {
+			return 0, err
+		}
+	} else {
+		current = scanOfflineRoot(root)
+	}
+	fmt.Printf("\n Offline scan of %s (read-only), %d files\n", safeName(source), len(current))
+
+	paths := make([]string, 0, len(current))
+	for p := range current {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	newCount, modifiedCount := 0, 0
+	for _, p := range paths {
+		state := current[p]
+		if baseline.KnownFiles[p] {
+			old, ok := baseline.FileStates[p]
+			if !ok {
+				continue
+			}
+			if changes := offlineChanges(old, state, tarPath == "" || state.Xattrs != nil); len(changes) > 0 {
+				fmt.Printf("MODIFIED: %s: %s\n", safeName(p), strings.Join(changes, ", "))
+				if caps := state.Xattrs[xattrCapability]; caps != "" && caps != old.Xattrs[xattrCapability] {
+					fmt.Printf("ALERT: File %s gained file capabilities: %s\n", safeName(p), describeCapabilities(caps))
+				}
+				modifiedCount++
+			}
+			continue
+		}
+		fmt.Printf("NEW: %s (%d bytes, mode %v, owner %d:%d)\n", safeName(p), state.Size, state.Mode, state.UID, state.GID)
+		newCount++
+		if denied, ok := baseline.DeniedHashes[state.Hash]; ok && state.Hash != "" {
+			fmt.Printf("ALERT: File %s matches previously denied file %s\n", safeName(p), safeName(denied))
+		}
+		// phan tich noi dung chi khi doc duoc file tu image da mount
+		var details []string
+		if tarPath == "" && state.Mode.IsRegular() {
+			details = newFileDetails(offlinePath(root, p), state)
+		} else {
+			details = metadataDetails(p, state)
+		}
+		for _, d := range details {
+			fmt.Printf("  - %s\n", d)
+		}
+	}
+
+	var deleted []string
+	for p := range baseline.KnownFiles {
+		if _, ok := current[p]; !ok && folderOf(p) != "" {
+			deleted = append(deleted, p)
+		}
+	}
+	sort.Strings(deleted)
+	for _, p := range deleted {
+		fmt.Printf("DELETED: %s\n", safeName(p))
+	}
+	fmt.Printf("\n Offline scan summary: %d new, %d modified, %d deleted\n", newCount, modifiedCount, len(deleted))
+	return newCount + modifiedCount + len(deleted), nil
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Cau hinh doi chieu file voi co so du lieu cua trinh quan ly goi
+type PackageConfig struct {
+	Folders     []string `json:"folders"`       // folder can doi chieu, vd: /usr/bin
+	DpkgInfoDir string   `json:"dpkg_info_dir"` // mac dinh /var/lib/dpkg/info
+	DpkgStatus  string   `json:"dpkg_status"`   // mac dinh /var/lib/dpkg/status
+	// File xuat tu: rpm -qa --qf '[%{=NAME} %{FILEDIGESTS} %{FILENAMES}\n]' > rpm_files.txt
+	RpmManifest string `json:"rpm_manifest"`
+	AutoApprove bool   `json:"auto_approve"` // tu dong duyet file khop voi goi
+}
+
+// Thong tin file theo goi so huu
+type packageFile struct {
+	Package  string
+	Digest   string // md5, sha1 hoac sha256 (phan biet qua do dai)
+	Conffile bool   // file cau hinh, admin co the sua hop le
+}
+
+// Ket qua doi chieu mot file
+type packageResult struct {
+	Owned    bool
+	Matches  bool
+	Package  string
+	Conffile bool
+	Message  string
+}
+
+var (
+	packageFiles  map[string]packageFile // path -> goi so huu
+	packageLoaded = make(map[string]time.Time)
+)
+
+func (c PackageConfig) dpkgInfoDir() string {
+	if c.DpkgInfoDir == "" {
+		return "/var/lib/dpkg/info"
+	}
+	return c.DpkgInfoDir
+}
+
+func (c PackageConfig) dpkgStatus() string {
+	if c.DpkgStatus == "" {
+		return "/var/lib/dpkg/status"
+	}
+	return c.DpkgStatus
+}
+
+// File nam trong folder can doi chieu voi goi
+func isPackageFolder(path string) bool {
+	for _, folder := range config.PackageVerify.Folders {
+		if isUnder(path, folder) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ham loadPackageDB nap lai database khi dpkg status hoac rpm manifest thay doi (cai/nang cap goi)
+func loadPackageDB() {
+	sources := []string{config.PackageVerify.dpkgStatus(), config.PackageVerify.RpmManifest}
+	stale := packageFiles == nil
+	for _, src := range sources {
+		if src == "" {
+			continue
+		}
+		if info, err := os.Stat(src); err == nil && !info.ModTime().Equal(packageLoaded[src]) {
+			packageLoaded[src] = info.ModTime()
+			stale = true
+		}
+	}
+	if !stale {
+		return
+	}
+
+	packageFiles = make(map[string]packageFile)
+	if err := loadDpkg(); err != nil {
+		fmt.Printf("Warning: Cannot read dpkg database: %v\n", err)
+	}
+	if config.PackageVerify.RpmManifest != "" {
+		if err := loadRpmManifest(config.PackageVerify.RpmManifest); err != nil {
+			fmt.Printf("Warning: Cannot read rpm manifest: %v\n", err)
+		}
+	}
+	fmt.Printf("Loaded %d package-owned files\n", len(packageFiles))
+}
+
+// Doc *.list (file so huu), *.md5sums (hash) va Conffiles trong status
+func loadDpkg() error {
+	dir := config.PackageVerify.dpkgInfoDir()
+	lists, err := filepath.Glob(filepath.Join(dir, "*.list"))
+	if err != nil || len(lists) == 0 {
+		return fmt.Errorf("no package lists in %s", dir)
+	}
+	for _, list := range lists {
+		pkg := strings.TrimSuffix(filepath.Base(list), ".list")
+		pkgName, _, _ := strings.Cut(pkg, ":") // bo hau to kien truc, vd: libc6:amd64
+		readLines(list, func(line string) {
+			if line != "" && line != "/." {
+				packageFiles[line] = packageFile{Package: pkgName}
+			}
+		})
+		readLines(filepath.Join(dir, pkg+".md5sums"), func(line string) {
+			digest, rel, ok := strings.Cut(line, "  ")
+			if ok {
+				packageFiles["/"+rel] = packageFile{Package: pkgName, Digest: digest}
+			}
+		})
+	}
+
+	pkgName := ""
+	inConffiles := false
+	return readLines(config.PackageVerify.dpkgStatus(), func(line string) {
+		switch {
+		case strings.HasPrefix(line, "Package: "):
+			pkgName = strings.TrimPrefix(line, "Package: ")
+			inConffiles = false
+		case line == "Conffiles:":
+			inConffiles = true
+		case inConffiles && strings.HasPrefix(line, " "):
+			fields := strings.Fields(line)
+			if len(fields) >= 2 {
+				packageFiles[fields[0]] = packageFile{Package: pkgName, Digest: fields[1], Conffile: true}
+			}
+		default:
+			inConffiles = false
+		}
+	})
+}
+
+// Doc file xuat tu rpm: "<ten goi> <digest> <path>", digest rong voi thu muc/symlink
+func loadRpmManifest(path string) error {
+	return readLines(path, func(line string) {
+		parts := strings.SplitN(line, " ", 3)
+		if len(parts) == 3 && strings.HasPrefix(parts[2], "/") {
+			packageFiles[parts[2]] = packageFile{Package: parts[0], Digest: parts[1]}
+		}
+	})
+}
+
+func readLines(path string, fn func(line string)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		fn(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// Tim file trong database, tinh ca /usr-merge (/bin/cat <-> /usr/bin/cat)
+func lookupPackageFile(path string) (packageFile, bool) {
+	candidates := []string{path}
+	if strings.HasPrefix(path, "/usr/") {
+		candidates = append(candidates, strings.TrimPrefix(path, "/usr"))
+	} else {
+		candidates = append(candidates, "/usr"+path)
+	}
+	for _, c := range candidates {
+		if pf, ok := packageFiles[c]; ok {
+			return pf, true
+		}
+	}
+	return packageFile{}, false
+}
+
+func digestFile(path, expected string) (string, error) {
+	var h hash.Hash
+	switch len(expected) {
+	case 32:
+		h = md5.New()
+	case 40:
+		h = sha1.New()
+	case 64:
+		h = sha256.New()
+	default:
+		return "", fmt.Errorf("unknown digest format")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// Ham verifyPackageFile doi chieu noi dung file voi hash cua goi so huu
+func verifyPackageFile(path string) packageResult {
+	pf, ok := lookupPackageFile(path)
+	if !ok {
+		return packageResult{Message: "does not belong to any installed package"}
+	}
+	result := packageResult{Owned: true, Package: pf.Package, Conffile: pf.Conffile}
+	if pf.Digest == "" {
+		result.Message = "owned by package " + pf.Package + " (no digest to verify)"
+		return result
+	}
+	digest, err := digestFile(path, pf.Digest)
+	if err != nil {
+		result.Message = fmt.Sprintf("owned by package %s, unable to verify: %v", pf.Package, err)
+		return result
+	}
+	if strings.EqualFold(digest, pf.Digest) {
+		result.Matches = true
+		result.Message = "matches package " + pf.Package
+		return result
+	}
+	if pf.Conffile {
+		result.Message = "configuration file differs from package " + pf.Package + " default"
+	} else {
+		result.Message = "content differs from package " + pf.Package
+	}
+	return result
+}
+
+// In ket qua doi chieu cho file da duyet bi sua
+func reportPackageModification(path string) {
+	result := verifyPackageFile(path)
+	switch {
+	case result.Matches:
+		fmt.Printf("File %s %s (package update)\n", safeName(path), result.Message)
+	case result.Owned && !result.Conffile && result.Message != "":
+		fmt.Printf("\nALERT: File %s %s\n", safeName(path), result.Message)
+	default:
+		fmt.Printf("Warning: File %s %s\n", safeName(path), result.Message)
+	}
+}
@@ -0,0 +1,102 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Ham findOpenFileHolders duyet /proc/*/fd, tra ve pid -> cac file dang mo thoa man match
+func findOpenFileHolders(match func(path string) bool) map[int][]string {
+	holders := make(map[int][]string)
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return holders
+	}
+	for _, p := range procs {
+		pid, err := strconv.Atoi(p.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join("/proc", p.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue // khong du quyen hoac process da ket thuc
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+			if match(target) {
+				holders[pid] = append(holders[pid], target)
+			}
+		}
+	}
+	return holders
+}
+
+func suspendProcess(pid int) error {
+	return syscall.Kill(pid, syscall.SIGSTOP)
+}
+
+// Ham readProcessInfo doc exe, user va chuoi process cha tu /proc
+func readProcessInfo(pid int) (ProcessInfo, bool) {
+	exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return ProcessInfo{}, false
+	}
+	_, ppid, uid := readProcStatus(pid)
+	info := ProcessInfo{PID: pid, Exe: exe, User: lookupUser(uid), Time: time.Now()}
+	// lan theo PPid toi init, gioi han do sau de tranh vong lap
+	for depth := 0; ppid > 0 && depth < 16; depth++ {
+		parentName, parentPPid, _ := readProcStatus(ppid)
+		if parentName == "" {
+			break
+		}
+		info.Chain = append(info.Chain, fmt.Sprintf("%s(%d)", parentName, ppid))
+		ppid = parentPPid
+	}
+	return info, true
+}
+
+// Doc Name, PPid va Uid tu /proc/<pid>/status
+func readProcStatus(pid int) (name string, ppid int, uid string) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return "", 0, ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		switch key {
+		case "Name":
+			name = fields[0]
+		case "PPid":
+			ppid, _ = strconv.Atoi(fields[0])
+		case "Uid":
+			uid = fields[0]
+		}
+	}
+	return name, ppid, uid
+}
+
+func lookupUser(uid string) string {
+	if u, err := user.LookupId(uid); err == nil {
+		return u.Username
+	}
+	return uid
+}
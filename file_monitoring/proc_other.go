@@ -0,0 +1,21 @@
+//go:build !linux
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Chua ho tro liet ke file dang mo ngoai Linux
+func findOpenFileHolders(match func(path string) bool) map[int][]string {
+	return nil
+}
+
+func suspendProcess(pid int) error {
+	return fmt.Errorf("suspending processes is not supported on %s", runtime.GOOS)
+}
+
+func readProcessInfo(pid int) (ProcessInfo, bool) {
+	return ProcessInfo{}, false
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func quarantineDir() string {
+	if config.QuarantineDir == "" {
+		return "quarantine"
+	}
+	return config.QuarantineDir
+}
+
+// Ten file trong thu muc cach ly: thoi diem + duong dan goc
+func quarantinePath(path string) (string, error) {
+	if err := os.MkdirAll(quarantineDir(), 0700); err != nil {
+		return "", fmt.Errorf("unable to create quarantine dir: %v", err)
+	}
+	name := time.Now().Format("20060102-150405") + "_" + strings.TrimLeft(strings.ReplaceAll(filepath.ToSlash(path), "/", "_"), "_")
+	return filepath.Join(quarantineDir(), name), nil
+}
+
+// Ham quarantineFile chuyen file vao thu muc cach ly, tra ve duong dan moi
+func quarantineFile(path string) (string, error) {
+	dest, err := quarantinePath(path)
+	if err != nil {
+		return "", err
+	}
+	if err := os.Rename(path, dest); err == nil {
+		return dest, nil
+	}
+	// khac phan vung: copy roi xoa file goc
+	if err := copyFile(path, dest, 0600); err != nil {
+		return "", err
+	}
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("unable to remove %s: %v", path, err)
+	}
+	return dest, nil
+}
+
+// Ham quarantineCopy luu ban sao cua file vao thu muc cach ly, giu nguyen file goc
+func quarantineCopy(path string) (string, error) {
+	dest, err := quarantinePath(path)
+	if err != nil {
+		return "", err
+	}
+	if err := copyFile(path, dest, 0600); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+func copyFile(src, dest string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("unable to open %s: %v", src, err)
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("unable to create %s: %v", dest, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("unable to copy %s: %v", src, err)
+	}
+	return out.Close()
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Cau hinh phan tich tinh cac file script moi
+type ScriptConfig struct {
+	Enabled    bool  `json:"enabled"`
+	MaxSize    int64 `json:"max_size"`    // bo qua file lon hon (bytes)
+	AlertScore int   `json:"alert_score"` // diem rui ro tu muc nay tro len thi bao ALERT
+}
+
+// Mot luat heuristic: mau nguy hiem va diem rui ro
+type scriptRule struct {
+	Name    string
+	Score   int
+	Pattern *regexp.Regexp
+}
+
+// Dong vi pham luat trong script
+type scriptHit struct {
+	Line int
+	Text string
+	Rule string
+}
+
+var scriptExtensions = map[string]bool{
+	".sh": true, ".bash": true, ".zsh": true, ".ksh": true, ".py": true, ".pl": true, ".pm": true, ".php": true,
+}
+
+var scriptInterpreters = regexp.MustCompile(`^#!\s*\S*(/env\s+)?\S*\b(sh|bash|zsh|ksh|dash|python[0-9.]*|perl|php)\b`)
+
+var scriptRules = []scriptRule{
+	{"download piped to interpreter", 35, regexp.MustCompile(`\b(curl|wget|fetch)\b[^|\n]*\|\s*(sudo\s+)?(ba|z|da|k)?sh\b|\b(curl|wget)\b[^|\n]*\|\s*(sudo\s+)?(python[0-9.]*|perl|php)\b`)},
+	{"base64 decoded and executed", 35, regexp.MustCompile(`base64\s+(-d|--decode|-D)\b[^\n]*\|\s*(ba|z)?sh\b|eval\s*\(\s*(base64_decode|gzinflate|str_rot13)|exec\s*\(\s*(base64\.b64decode|__import__\(\s*['"]base64)|eval\s+["']?\$\([^)]*base64`)},
+	{"reverse shell", 45, regexp.MustCompile(`/dev/(tcp|udp)/|\bnc(at)?\b[^\n]*\s-[ce]\s*\S*sh\b|\bbash\s+-i\s*>&|\bmkfifo\b[^\n]*\bnc\b|pty\.spawn\(|os\.dup2\(\s*\w+\.fileno\(\)|\bfsockopen\s*\(|socket\s*\(\s*S[^\n]*exec`)},
+	{"crontab modification", 20, regexp.MustCompile(`\bcrontab\s+(-[a-z]*\s+)*-\s*$|\bcrontab\s+-r\b|\|\s*crontab\b|/etc/cron|/var/spool/cron`)},
+	{"persistence or privilege change", 15, regexp.MustCompile(`authorized_keys|/etc/sudoers|systemctl\s+enable|\.bashrc|/etc/rc\.local|chmod\s+[ugo]*\+?[0-7]*s\b|chmod\s+[0-7]?[4-7][0-7]{3}\b`)},
+	{"history or log tampering", 15, regexp.MustCompile(`history\s+-c|unset\s+HISTFILE|HISTFILE=/dev/null|>\s*/var/log/\S+|shred\s+[^\n]*/var/log`)},
+	{"execution from temporary directory", 10, regexp.MustCompile(`chmod\s+\+?[0-7]*x?\s+/(tmp|dev/shm|var/tmp)/|\s/(tmp|dev/shm)/\S+\s*(&|$)`)},
+	{"obfuscation", 20, regexp.MustCompile(`(\\x[0-9a-fA-F]{2}){10,}|[A-Za-z0-9+/]{200,}={0,2}|(chr\(\d+\)\s*[.+]\s*){5,}|\$\{IFS\}|eval\s*\$\(\s*echo`)},
+}
+
+func (c ScriptConfig) maxSize() int64 {
+	if c.MaxSize <= 0 {
+		return 1 << 20
+	}
+	return c.MaxSize
+}
+
+func (c ScriptConfig) alertScore() int {
+	if c.AlertScore <= 0 {
+		return 30
+	}
+	return c.AlertScore
+}
+
+// Nhan dien script qua duoi file hoac dong shebang
+func isScript(path string, head []byte) bool {
+	if scriptExtensions[strings.ToLower(filepath.Ext(path))] {
+		return true
+	}
+	firstLine, _, _ := bytes.Cut(head, []byte("\n"))
+	return scriptInterpreters.Match(firstLine)
+}
+
+// Ham analyzeScript quet tung dong script, tra ve diem rui ro (toi da 100) va cac dong vi pham
+func analyzeScript(path string) (int, []scriptHit, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to open script: %v", err)
+	}
+	defer f.Close()
+	head := make([]byte, 256)
+	n, _ := f.Read(head)
+	if !isScript(path, head[:n]) {
+		return 0, nil, nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, nil, fmt.Errorf("unable to read script: %v", err)
+	}
+
+	score := 0
+	matched := make(map[string]bool)
+	var hits []scriptHit
+	scanner := bufio.NewScanner(io.LimitReader(f, config.ScriptAnalysis.maxSize()))
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		for _, rule := range scriptRules {
+			if !rule.Pattern.MatchString(line) {
+				continue
+			}
+			if !matched[rule.Name] {
+				matched[rule.Name] = true
+				score += rule.Score
+			}
+			text := strings.TrimSpace(line)
+			if len(text) > 120 {
+				text = text[:120] + "..."
+			}
+			hits = append(hits, scriptHit{Line: lineNo, Text: text, Rule: rule.Name})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, nil, fmt.Errorf("unable to read script: %v", err)
+	}
+	return min(score, 100), hits, nil
+}
+
+// Tao cac dong mo ta ket qua phan tich script cho prompt
+func scriptDetails(path string) []string {
+	score, hits, err := analyzeScript(path)
+	if err != nil {
+		fmt.Printf("Warning: Cannot analyze script %s: %v\n", safeName(path), err)
+		return nil
+	}
+	if score == 0 {
+		return nil
+	}
+	if score >= config.ScriptAnalysis.alertScore() {
+		fmt.Printf("ALERT: Script %s has risk score %d/100\n", safeName(path), score)
+	} else {
+		fmt.Printf("Warning: Script %s has risk score %d/100\n", safeName(path), score)
+	}
+	details := []string{fmt.Sprintf("Script risk score %d/100, offending lines:", score)}
+	for i, hit := range hits {
+		if i == 10 {
+			details = append(details, fmt.Sprintf("  ... and %d more", len(hits)-i))
+			break
+		}
+		details = append(details, fmt.Sprintf("  line %d [%s]: %s", hit.Line, hit.Rule, safeName(hit.Text)))
+	}
+	return details
+}
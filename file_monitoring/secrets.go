@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Cau hinh quet secret/private key trong file van ban
+type SecretConfig struct {
+	Enabled    bool    `json:"enabled"`
+	MaxSize    int64   `json:"max_size"`    // bo qua file lon hon (bytes)
+	MinEntropy float64 `json:"min_entropy"` // nguong entropy cho gia tri credential chung chung
+	Quarantine bool    `json:"quarantine"`  // chuyen file chua secret vao quarantine_dir
+}
+
+// Mau secret co dinh dang ro rang
+type secretPattern struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// Secret tim thay (da che)
+type secretMatch struct {
+	Line     int
+	Kind     string
+	Redacted string
+}
+
+var (
+	privateKeyBegin = regexp.MustCompile(`-----BEGIN ((RSA|EC|DSA|OPENSSH|ENCRYPTED|PGP) )?PRIVATE KEY( BLOCK)?-----`)
+	privateKeyEnd   = regexp.MustCompile(`-----END ((RSA|EC|DSA|OPENSSH|ENCRYPTED|PGP) )?PRIVATE KEY( BLOCK)?-----`)
+	// key nam tren mot dong (vd: chuoi JSON co "\n"): phan du lieu sau header
+	privateKeyInline = regexp.MustCompile(`(-----BEGIN ((RSA|EC|DSA|OPENSSH|ENCRYPTED|PGP) )?PRIVATE KEY( BLOCK)?-----)(.+?)(-----END |$)`)
+)
+
+var secretPatterns = []secretPattern{
+	{"private key", privateKeyBegin},
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"AWS secret key", regexp.MustCompile(`(?i)aws_?secret_?access_?key\s*[=:]\s*["']?([A-Za-z0-9/+=]{40})`)},
+	{"GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b|\bgithub_pat_[A-Za-z0-9_]{60,}`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{"Stripe key", regexp.MustCompile(`\b(sk|rk)_live_[0-9a-zA-Z]{24,}`)},
+	{"JWT", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+}
+
+// Gan gia tri cho bien co ten giong credential: password=..., api_key: ...
+var credentialAssignment = regexp.MustCompile(`(?i)\b\w*(password|passwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|auth)\w*\s*[:=]\s*["']?([^\s"',;]{12,})`)
+
+func (c SecretConfig) maxSize() int64 {
+	if c.MaxSize <= 0 {
+		return 1 << 20
+	}
+	return c.MaxSize
+}
+
+func (c SecretConfig) minEntropy() float64 {
+	if c.MinEntropy <= 0 {
+		return 3.5
+	}
+	return c.MinEntropy
+}
+
+// Entropy Shannon (bit/ky tu) cua chuoi
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	entropy := 0.0
+	total := float64(len([]rune(s)))
+	for _, c := range counts {
+		p := float64(c) / total
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// Che secret, chi giu lai 4 ky tu dau
+func redact(secret string) string {
+	if len(secret) <= 8 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:4] + strings.Repeat("*", min(len(secret)-4, 16)) + fmt.Sprintf("(%d chars)", len(secret))
+}
+
+// Ham redactKeyData thay mot dong du lieu base64 ben trong khoi private key
+func redactKeyData(line string) string {
+	return fmt.Sprintf("**** private key data (%d chars)", len(strings.TrimSpace(line)))
+}
+
+// Ham redactSecrets thay cac secret trong dong van ban bang ban da che
+func redactSecrets(line string) string {
+	line = privateKeyInline.ReplaceAllString(line, "${1}****${6}")
+	for _, p := range secretPatterns {
+		line = p.Pattern.ReplaceAllStringFunc(line, func(m string) string {
+			if p.Name == "private key" {
+				return m // header PEM khong phai la secret
+			}
+			return redact(m)
+		})
+	}
+	return credentialAssignment.ReplaceAllStringFunc(line, func(m string) string {
+		sub := credentialAssignment.FindStringSubmatch(m)
+		if strings.Contains(sub[2], "****") {
+			return m // da duoc che o buoc tren
+		}
+		return strings.Replace(m, sub[2], redact(sub[2]), 1)
+	})
+}
+
+// Ham scanSecrets tim private key, token va credential entropy cao trong file van ban
+func scanSecrets(path string) ([]secretMatch, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open file: %v", err)
+	}
+	defer f.Close()
+	head := make([]byte, 8192)
+	n, _ := f.Read(head)
+	if bytes.IndexByte(head[:n], 0) >= 0 {
+		return nil, nil // file nhi phan
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("unable to read file: %v", err)
+	}
+
+	var matches []secretMatch
+	scanner := bufio.NewScanner(io.LimitReader(f, config.SecretScan.maxSize()))
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		found := false
+		for _, p := range secretPatterns {
+			if m := p.Pattern.FindString(line); m != "" {
+				found = true
+				redacted := redact(m)
+				if p.Name == "private key" {
+					redacted = m
+				}
+				matches = append(matches, secretMatch{Line: lineNo, Kind: p.Name, Redacted: redacted})
+			}
+		}
+		if found {
+			continue
+		}
+		if sub := credentialAssignment.FindStringSubmatch(line); sub != nil && shannonEntropy(sub[2]) >= config.SecretScan.minEntropy() {
+			matches = append(matches, secretMatch{Line: lineNo, Kind: "high-entropy credential (" + strings.ToLower(sub[1]) + ")", Redacted: redact(sub[2])})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read file: %v", err)
+	}
+	return matches, nil
+}
+
+// Ham checkSecrets quet file, bao cao secret da che va cach ly file neu duoc cau hinh
+func checkSecrets(path string) (details []string, quarantined bool) {
+	matches, err := scanSecrets(path)
+	if err != nil {
+		fmt.Printf("Warning: Cannot scan %s for secrets: %v\n", safeName(path), err)
+		return nil, false
+	}
+	if len(matches) == 0 {
+		return nil, false
+	}
+	fmt.Printf("\nALERT: Possible secrets found in %s (%d matches)\n", safeName(path), len(matches))
+	details = append(details, fmt.Sprintf("Possible secrets (%d):", len(matches)))
+	for i, m := range matches {
+		if i == 10 {
+			details = append(details, fmt.Sprintf("  ... and %d more", len(matches)-i))
+			break
+		}
+		details = append(details, fmt.Sprintf("  line %d [%s]: %s", m.Line, m.Kind, m.Redacted))
+	}
+	if config.SecretScan.Quarantine {
+		dest, err := quarantineFile(path)
+		if err != nil {
+			fmt.Printf("Unable to quarantine %s: %v\n", safeName(path), err)
+			return details, false
+		}
+		for _, line := range details {
+			fmt.Println(line)
+		}
+		fmt.Printf("Quarantined %s to %s\n", safeName(path), dest)
+		return details, true
+	}
+	return details, false
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// Chua ho tro atime tren he dieu hanh nay
+func fileAccessTime(info os.FileInfo) time.Time {
+	return time.Time{}
+}
+
+// Chua ho tro uid/gid tren he dieu hanh nay
+func fileOwner(info os.FileInfo) (uid, gid int, ok bool) {
+	return -1, -1, false
+}
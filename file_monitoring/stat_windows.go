@@ -0,0 +1,22 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// Lay thoi diem truy cap cuoi (atime) cua file
+func fileAccessTime(info os.FileInfo) time.Time {
+	if data, ok := info.Sys().(*syscall.Win32FileAttributeData); ok {
+		return time.Unix(0, data.LastAccessTime.Nanoseconds())
+	}
+	return time.Time{}
+}
+
+// Windows khong co uid/gid
+func fileOwner(info os.FileInfo) (uid, gid int, ok bool) {
+	return -1, -1, false
+}
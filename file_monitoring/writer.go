@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cau hinh xac dinh process da ghi file
+type WriterConfig struct {
+	Enabled     bool `json:"enabled"`
+	UseFanotify bool `json:"use_fanotify"` // can quyen root (CAP_SYS_ADMIN), neu loi se quet /proc/*/fd
+}
+
+// Thong tin mot process (pid, exe, user, chuoi process cha)
+type ProcessInfo struct {
+	PID    int       `json:"pid"`
+	Exe    string    `json:"exe"`
+	User   string    `json:"user"`
+	Chain  []string  `json:"chain,omitempty"` // cac process cha, vd: bash(1200), sshd(900)
+	Source string    `json:"source"`          // fanotify hoac proc
+	Time   time.Time `json:"time"`
+}
+
+var (
+	writersMu     sync.Mutex
+	recentWriters = make(map[string]ProcessInfo) // path -> process ghi gan nhat (tu fanotify)
+)
+
+// Ghi nhan process vua ghi file, goi tu goroutine fanotify
+func recordWriter(path string, info ProcessInfo) {
+	writersMu.Lock()
+	defer writersMu.Unlock()
+	recentWriters[path] = info
+}
+
+// Ham lookupWriter tim process da ghi file: uu tien su kien fanotify, neu khong co thi tim process dang mo file
+func lookupWriter(path string) (ProcessInfo, bool) {
+	writersMu.Lock()
+	info, ok := recentWriters[path]
+	if ok {
+		delete(recentWriters, path)
+	}
+	writersMu.Unlock()
+	if ok {
+		return info, true
+	}
+
+	holders := findOpenFileHolders(func(target string) bool { return target == path })
+	for pid := range holders {
+		if info, ok := readProcessInfo(pid); ok {
+			info.Source = "proc"
+			return info, true
+		}
+	}
+	return ProcessInfo{}, false
+}
+
+func (w ProcessInfo) String() string {
+	s := fmt.Sprintf("pid %d %s (user %s, via %s)", w.PID, w.Exe, w.User, w.Source)
+	if len(w.Chain) > 0 {
+		s += ", parents: " + strings.Join(w.Chain, " <- ")
+	}
+	return s
+}
+
+// Dong mo ta process ghi file cho prompt
+func writerDetails(path string) (ProcessInfo, []string) {
+	writer, ok := lookupWriter(path)
+	if !ok {
+		return ProcessInfo{}, []string{"Written by: unknown process"}
+	}
+	return writer, []string{"Written by: " + writer.String()}
+}
+
+// Xoa cac su kien fanotify cu ma khong co file tuong ung
+func pruneWriters(now time.Time) {
+	writersMu.Lock()
+	defer writersMu.Unlock()
+	for path, info := range recentWriters {
+		if now.Sub(info.Time) > 2*checkInterval {
+			delete(recentWriters, path)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	xattrCapability   = "security.capability"
+	xattrSELinux      = "security.selinux"
+	xattrACLAccess    = "system.posix_acl_access"
+	xattrACLDefault   = "system.posix_acl_default"
+	xattrBinaryPrefix = "hex:"
+)
+
+// Ten cac capability theo so bit (linux/capability.h)
+var capabilityNames = []string{
+	"cap_chown", "cap_dac_override", "cap_dac_read_search", "cap_fowner", "cap_fsetid", "cap_kill",
+	"cap_setgid", "cap_setuid", "cap_setpcap", "cap_linux_immutable", "cap_net_bind_service",
+	"cap_net_broadcast", "cap_net_admin", "cap_net_raw", "cap_ipc_lock", "cap_ipc_owner",
+	"cap_sys_module", "cap_sys_rawio", "cap_sys_chroot", "cap_sys_ptrace", "cap_sys_pacct",
+	"cap_sys_admin", "cap_sys_boot", "cap_sys_nice", "cap_sys_resource", "cap_sys_time",
+	"cap_sys_tty_config", "cap_mknod", "cap_lease", "cap_audit_write", "cap_audit_control",
+	"cap_setfcap", "cap_mac_override", "cap_mac_admin", "cap_syslog", "cap_wake_alarm",
+	"cap_block_suspend", "cap_audit_read", "cap_perfmon", "cap_bpf", "cap_checkpoint_restore",
+}
+
+// Gia tri van ban (vd: nhan SELinux) giu nguyen, gia tri nhi phan luu dang hex
+func encodeXattr(value []byte) string {
+	text := strings.TrimRight(string(value), "\x00")
+	if utf8.ValidString(text) && !strings.ContainsFunc(text, func(r rune) bool { return !unicode.IsPrint(r) }) {
+		return text
+	}
+	return xattrBinaryPrefix + hex.EncodeToString(value)
+}
+
+// Ham describeCapabilities giai ma vfs_cap_data thanh dang "cap_net_raw,cap_setuid=ep"
+func describeCapabilities(encoded string) string {
+	raw, err := hex.DecodeString(strings.TrimPrefix(encoded, xattrBinaryPrefix))
+	if err != nil || len(raw) < 12 {
+		return encoded
+	}
+	magic := binary.LittleEndian.Uint32(raw[0:4])
+	permitted := uint64(binary.LittleEndian.Uint32(raw[4:8]))
+	inheritable := uint64(binary.LittleEndian.Uint32(raw[8:12]))
+	if len(raw) >= 20 {
+		permitted |= uint64(binary.LittleEndian.Uint32(raw[12:16])) << 32
+		inheritable |= uint64(binary.LittleEndian.Uint32(raw[16:20])) << 32
+	}
+	var names []string
+	for bit := 0; bit < 64; bit++ {
+		if (permitted|inheritable)&(1<<bit) == 0 {
+			continue
+		}
+		if bit < len(capabilityNames) {
+			names = append(names, capabilityNames[bit])
+		} else {
+			names = append(names, fmt.Sprintf("cap_%d", bit))
+		}
+	}
+	flags := "="
+	if magic&1 != 0 {
+		flags += "e"
+	}
+	if inheritable != 0 {
+		flags += "i"
+	}
+	if permitted != 0 {
+		flags += "p"
+	}
+	return strings.Join(names, ",") + flags
+}
+
+// Ham checkXattrChanges so sanh xattr cua file da duyet voi baseline va canh bao khi co thay doi
+func checkXattrChanges(path string, old, cur map[string]string) {
+	if old[xattrCapability] != cur[xattrCapability] {
+		if cur[xattrCapability] == "" {
+			fmt.Printf("Warning: File %s lost its capabilities\n", safeName(path))
+		} else {
+			fmt.Printf("\nALERT: File %s gained capabilities: %s\n", safeName(path), describeCapabilities(cur[xattrCapability]))
+		}
+	}
+	if old[xattrSELinux] != cur[xattrSELinux] {
+		fmt.Printf("\nALERT: SELinux label of %s changed: %q -> %q\n", safeName(path), old[xattrSELinux], cur[xattrSELinux])
+	}
+	for _, name := range []string{xattrACLAccess, xattrACLDefault} {
+		if old[name] != cur[name] {
+			fmt.Printf("\nALERT: ACL %s of %s changed\n", name, safeName(path))
+		}
+	}
+
+	var others []string
+	for name := range cur {
+		if old[name] != cur[name] && !isWatchedXattr(name) {
+			others = append(others, name)
+		}
+	}
+	for name := range old {
+		if _, ok := cur[name]; !ok && !isWatchedXattr(name) {
+			others = append(others, name)
+		}
+	}
+	if len(others) > 0 {
+		sort.Strings(others)
+		fmt.Printf("Warning: Extended attributes of %s changed: %s\n", safeName(path), strings.Join(others, ", "))
+	}
+}
+
+func isWatchedXattr(name string) bool {
+	return name == xattrCapability || name == xattrSELinux || name == xattrACLAccess || name == xattrACLDefault
+}
+
+func sameXattrs(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, value := range a {
+		if v, ok := b[name]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
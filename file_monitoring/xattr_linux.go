@@ -0,0 +1,41 @@
+//go:build linux
+
+package main
+
+import (
+	"strings"
+	"syscall"
+)
+
+// Ham readXattrs doc tat ca extended attribute cua file (name -> gia tri da ma hoa)
+func readXattrs(path string) (map[string]string, error) {
+	size, err := syscall.Listxattr(path, nil)
+	if err == syscall.ENOTSUP {
+		return nil, nil // file system khong ho tro xattr
+	}
+	if err != nil || size == 0 {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	size, err = syscall.Listxattr(path, buf)
+	if err != nil {
+		return nil, err
+	}
+	attrs := make(map[string]string)
+	for _, name := range strings.Split(strings.TrimRight(string(buf[:size]), "\x00"), "\x00") {
+		if name == "" {
+			continue
+		}
+		vsize, err := syscall.Getxattr(path, name, nil)
+		if err != nil {
+			continue
+		}
+		value := make([]byte, vsize)
+		vsize, err = syscall.Getxattr(path, name, value)
+		if err != nil {
+			continue
+		}
+		attrs[name] = encodeXattr(value[:vsize])
+	}
+	return attrs, nil
+}
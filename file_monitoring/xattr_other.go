@@ -0,0 +1,8 @@
+//go:build !linux
+
+package main
+
+// Chua ho tro doc xattr ngoai Linux
+func readXattrs(path string) (map[string]string, error) {
+	return nil, nil
+}
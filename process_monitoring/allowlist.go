@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Cau hinh che do allowlist: moi process khong co trong baseline deu phai duyet
+type AllowlistConfig struct {
+	Enabled         bool   `json:"enabled"`
+	LearningMinutes int    `json:"learning_minutes"` // trong thoi gian hoc, process moi duoc ghi nhan tu dong
+	KeyBy           string `json:"key_by"`           // "name" (mac dinh), "path" hoac "hash"
+}
+
+// Ham learningUntil tra ve thoi diem ket thuc giai doan hoc
+func learningUntil() time.Time {
+	return baseline.LearningStarted.Add(time.Duration(config.Allowlist.LearningMinutes) * time.Minute)
+}
+
+// Kernel thread khong co file thuc thi, khong dua vao allowlist
+func isKernelThread(p ProcessInfo) bool {
+	return runtime.GOOS == "linux" && (p.PID == 2 || p.PPID == 2)
+}
+
+// Ham processKey tra ve khoa trong KnownProcess theo key_by, lui ve ten neu khong doc duoc file thuc thi
+func processKey(p ProcessInfo) (string, string) {
+	name := normalizeProcessName(p.Name)
+	switch config.Allowlist.KeyBy {
+	case "path":
+		if p.Exe != "" {
+			return exePath(p), ""
+		}
+		return name, "Executable path unavailable, keyed by name"
+	case "hash":
+		hash, err := hashExecutable(p)
+		if err == nil {
+			return "sha256:" + hash, ""
+		}
+		return name, fmt.Sprintf("Cannot hash executable (%v), keyed by name", err)
+	}
+	return name, ""
+}
+
+// Ham checkAllowlist so sanh cac process can duyet voi KnownProcess (all dung de tim process con).
+// Process khop rule allow duoc coi nhu da duyet theo ten/duong dan nhung van xac minh file thuc thi
+func checkAllowlist(processes, all []ProcessInfo, allowed map[int]bool) bool {
+	now := time.Now()
+	if baseline.LearningStarted.IsZero() {
+		baseline.LearningStarted = now
+		if err := saveBaseline(); err != nil {
+			fmt.Printf("Error saving baseline: %v\n", err)
+		}
+	}
+	learning := now.Before(learningUntil())
+	if learning {
+		fmt.Printf("Learning mode: new processes are recorded automatically until %s\n", learningUntil().Format(time.RFC3339))
+	}
+
+	// gom cac process cung khoa de chi hoi mot lan
+	unknown := make(map[string][]ProcessInfo)
+	denied := make(map[string][]ProcessInfo)
+	known := make(map[string][]ProcessInfo)
+	notes := make(map[string]string)
+	for _, proc := range processes {
+		// zombie da ket thuc, khong con file thuc thi
+		if proc.PID == os.Getpid() || isKernelThread(proc) || proc.State == "Z" {
+			continue
+		}
+		key, note := processKey(proc)
+		if baseline.KnownProcess[key] || (allowed[proc.PID] && !strings.HasPrefix(key, "sha256:")) {
+			known[key] = append(known[key], proc)
+			continue
+		}
+		if baseline.DeniedProcess[key] {
+			denied[key] = append(denied[key], proc)
+			continue
+		}
+		unknown[key] = append(unknown[key], proc)
+		if note != "" {
+			notes[key] = note
+		}
+	}
+	// muc da duyet theo ten/duong dan: xac minh file thuc thi (khoa theo hash thi da xac minh san)
+	alerted, changed := false, false
+	for key, procs := range known {
+		if strings.HasPrefix(key, "sha256:") {
+			continue
+		}
+		if learning {
+			recordBinaries(key, procs)
+			changed = true
+		} else if verifyBinaries(key, procs, all) {
+			alerted = true
+		}
+	}
+
+	// da tu choi truoc do: khong hoi lai, chi thuc hien hanh dong
+	for key, procs := range denied {
+		fmt.Printf("\nALERT: Denied process is running: %s\n", procs[0].Name)
+		respondToDenied(key, procs, all)
+	}
+
+	keys := make([]string, 0, len(unknown))
+	for key := range unknown {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		procs := unknown[key]
+		if learning {
+			baseline.KnownProcess[key] = true
+			recordBinaries(key, procs)
+			changed = true
+			fmt.Printf("Learning: recorded process %s (%s)\n", procs[0].Name, key)
+			continue
+		}
+		fmt.Printf("\nALERT: Unknown process is running: %s\n", procs[0].Name)
+		details := []string{"Baseline key: " + key}
+		if notes[key] != "" {
+			details = append(details, notes[key])
+		}
+		for _, proc := range procs {
+			details = append(details, proc.describe()...)
+		}
+		if promptApproval(procs[0].Name, details...) {
+			approveProcess(procs[0].Name, key, procs)
+		} else {
+			denyProcess(procs[0].Name, key, procs, all)
+		}
+	}
+	if changed {
+		if err := saveBaseline(); err != nil {
+			fmt.Printf("Error saving baseline: %v\n", err)
+		}
+	}
+	if learning {
+		fmt.Printf("Learning: %d processes in baseline\n", len(baseline.KnownProcess))
+	}
+	return alerted || (len(keys) > 0 && !learning)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// File thuc thi da duoc duyet cho mot muc trong baseline
+type ApprovedBinary struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// Cache hash theo device/inode/mtime de khong phai doc lai file moi lan quet
+var hashCache = make(map[string]string)
+
+// Duong dan doc duoc file thuc thi, /proc/<pid>/exe van doc duoc ca khi file da bi xoa hoac thay the
+func exeFile(p ProcessInfo) string {
+	if runtime.GOOS == "linux" {
+		return fmt.Sprintf("/proc/%d/exe", p.PID)
+	}
+	return p.Exe
+}
+
+// Ham hashExecutable tinh sha256 cua file thuc thi cua process
+func hashExecutable(p ProcessInfo) (string, error) {
+	path := exeFile(p)
+	if path == "" {
+		return "", fmt.Errorf("executable path unavailable")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	id := fileID(path, info)
+	if hash, ok := hashCache[id]; ok {
+		return hash, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	hash := fmt.Sprintf("%x", h.Sum(nil))
+	hashCache[id] = hash
+	return hash, nil
+}
+
+// Duong dan file thuc thi, bo hau to " (deleted)" khi file da bi thay the (vd. sau khi nang cap)
+func exePath(p ProcessInfo) string {
+	return strings.TrimSuffix(p.Exe, " (deleted)")
+}
+
+// Ham isApprovedBinary kiem tra ca duong dan va hash deu da duoc duyet
+func isApprovedBinary(key, path, hash string) bool {
+	for _, b := range baseline.ApprovedBinaries[key] {
+		if b.Path == path && b.Hash == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// Ham recordBinaries ghi nhan file thuc thi cua cac process vao muc baseline
+func recordBinaries(key string, procs []ProcessInfo) {
+	for _, proc := range procs {
+		hash, err := hashExecutable(proc)
+		if err != nil || isApprovedBinary(key, exePath(proc), hash) {
+			continue
+		}
+		baseline.ApprovedBinaries[key] = append(baseline.ApprovedBinaries[key], ApprovedBinary{Path: exePath(proc), Hash: hash})
+	}
+}
+
+// Ham verifyBinaries canh bao khi process co ten/duong dan da duyet (hoac khop rule allow) chay tu
+// file thuc thi chua duoc duyet
+func verifyBinaries(key string, procs []ProcessInfo, all []ProcessInfo) bool {
+	// muc cu da duyet nhung chua co file thuc thi: ghi nhan lan dau, khong canh bao.
+	// Process chi khop rule allow thi khong tu tin file dau tien, phai hoi
+	known := baseline.KnownProcess[key]
+	if known && len(baseline.ApprovedBinaries[key]) == 0 {
+		recordBinaries(key, procs)
+		if len(baseline.ApprovedBinaries[key]) > 0 {
+			fmt.Printf("Recorded executable of approved process %s\n", key)
+			if err := saveBaseline(); err != nil {
+				fmt.Printf("Error saving baseline: %v\n", err)
+			}
+		}
+		return false
+	}
+
+	// gom theo file thuc thi de chi hoi mot lan
+	type binary struct{ path, hash string }
+	unapproved := make(map[binary][]ProcessInfo)
+	for _, proc := range procs {
+		hash, err := hashExecutable(proc)
+		if err != nil {
+			continue // khong doc duoc (khong du quyen), khong the xac minh
+		}
+		if isApprovedBinary(key, exePath(proc), hash) {
+			continue
+		}
+		b := binary{exePath(proc), hash}
+		unapproved[b] = append(unapproved[b], proc)
+	}
+	binaries := make([]binary, 0, len(unapproved))
+	for b := range unapproved {
+		binaries = append(binaries, b)
+	}
+	sort.Slice(binaries, func(i, j int) bool { return binaries[i].path < binaries[j].path })
+
+	for _, b := range binaries {
+		procs := unapproved[b]
+		hashKey := "sha256:" + b.hash
+		if known {
+			fmt.Printf("\nALERT: Approved process %s is running from an unapproved binary %s (sha256 %s)\n", key, b.path, b.hash)
+		} else {
+			fmt.Printf("\nALERT: Process %s allowed by command line rule is running from an unapproved binary %s (sha256 %s)\n", key, b.path, b.hash)
+		}
+		if baseline.DeniedProcess[hashKey] {
+			respondToDenied(hashKey, procs, all)
+			continue
+		}
+		details := []string{"Approved executables: none"}
+		if len(baseline.ApprovedBinaries[key]) > 0 {
+			details[0] = "Approved executables:"
+		}
+		for _, a := range baseline.ApprovedBinaries[key] {
+			details = append(details, fmt.Sprintf("  %s (sha256 %.16s)", a.Path, a.Hash))
+		}
+		for _, proc := range procs {
+			details = append(details, proc.describe()...)
+		}
+		if promptApproval(fmt.Sprintf("%s binary %s", key, b.path), details...) {
+			baseline.ApprovedBinaries[key] = append(baseline.ApprovedBinaries[key], ApprovedBinary{Path: b.path, Hash: b.hash})
+			if known {
+				approveProcess(procs[0].Name, key, nil)
+				continue
+			}
+			// chi duyet file thuc thi, ten process van chi duoc phep qua rule allow
+			if err := saveBaseline(); err != nil {
+				fmt.Printf("Error saving baseline: %v\n", err)
+			} else {
+				fmt.Printf("Executable %s approved for %s\n", b.path, key)
+			}
+			writeAudit(auditEntry{Event: "approved", Process: procs[0].Name, Key: hashKey})
+		} else {
+			denyProcess(procs[0].Name, hashKey, procs, all)
+		}
+	}
+	return len(binaries) > 0
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Rule tren command line: rule dau tien khop quyet dinh cho phep (allow) hay tu choi (deny)
+type CmdlineRule struct {
+	Name      string   `json:"name"`
+	Processes []string `json:"processes"` // ten process (glob), rong = moi process
+	Regex     string   `json:"regex"`     // regex tren toan bo command line (cac tham so noi bang dau cach)
+	Tokens    []string `json:"tokens"`    // cac tham so (glob, "*" khop moi ky tu) phai xuat hien theo thu tu
+	Action    string   `json:"action"`    // "allow" hoac "deny"
+
+	regex  *regexp.Regexp
+	tokens []*regexp.Regexp
+}
+
+// Da canh bao process nao bi rule deny, de khong lap lai moi lan quet
+var reportedCmdline = newAlertSet()
+
+// Ham globRegexp chuyen glob thanh regex, "*" khop ca "/"
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	quoted := regexp.QuoteMeta(pattern)
+	quoted = strings.ReplaceAll(quoted, `\*`, ".*")
+	quoted = strings.ReplaceAll(quoted, `\?`, ".")
+	return regexp.Compile("^" + quoted + "$")
+}
+
+// Ham compileCmdlineRules kiem tra va bien dich cac rule khi nap config
+func compileCmdlineRules() error {
+	for i := range config.CmdlineRules {
+		rule := &config.CmdlineRules[i]
+		if rule.Action != "allow" && rule.Action != "deny" {
+			return fmt.Errorf("cmdline rule %q: action must be \"allow\" or \"deny\"", rule.Name)
+		}
+		if rule.Regex == "" && len(rule.Tokens) == 0 {
+			return fmt.Errorf("cmdline rule %q: regex or tokens is required", rule.Name)
+		}
+		if rule.Regex != "" {
+			re, err := regexp.Compile(rule.Regex)
+			if err != nil {
+				return fmt.Errorf("cmdline rule %q: invalid regex: %v", rule.Name, err)
+			}
+			rule.regex = re
+		}
+		for _, token := range rule.Tokens {
+			re, err := globRegexp(token)
+			if err != nil {
+				return fmt.Errorf("cmdline rule %q: invalid token %q: %v", rule.Name, token, err)
+			}
+			rule.tokens = append(rule.tokens, re)
+		}
+	}
+	return nil
+}
+
+// Ham matchTokens kiem tra cac token xuat hien theo thu tu trong argv (so voi ca ten file cua tham so)
+func matchTokens(tokens []*regexp.Regexp, argv []string) bool {
+	i := 0
+	for _, arg := range argv {
+		if i == len(tokens) {
+			break
+		}
+		if tokens[i].MatchString(arg) || tokens[i].MatchString(filepath.Base(arg)) {
+			i++
+		}
+	}
+	return i == len(tokens)
+}
+
+// Ham matches kiem tra process khop rule (ca regex va tokens neu co)
+func (r CmdlineRule) matches(p ProcessInfo) bool {
+	if !matchGlob(normalizeAll(r.Processes), normalizeProcessName(p.Name)) || len(p.Cmdline) == 0 {
+		return false
+	}
+	if r.regex != nil && !r.regex.MatchString(strings.Join(p.Cmdline, " ")) {
+		return false
+	}
+	return len(r.tokens) == 0 || matchTokens(r.tokens, p.Cmdline)
+}
+
+// Ham cmdlineRuleFor tra ve rule dau tien khop process
+func cmdlineRuleFor(p ProcessInfo) (CmdlineRule, bool) {
+	for _, rule := range config.CmdlineRules {
+		if rule.matches(p) {
+			return rule, true
+		}
+	}
+	return CmdlineRule{}, false
+}
+
+var (
+	// key=value hoac key:value voi key nhay cam
+	secretAssignment = regexp.MustCompile(`(?i)((?:pass(?:word|wd)?|pwd|secret|token|api[_-]?key|access[_-]?key|auth|credentials?)[a-z_-]*[=:])(\S+)`)
+	// token trong header Authorization
+	bearerToken = regexp.MustCompile(`(?i)((?:bearer|basic)\s+)\S+`)
+	// thong tin dang nhap trong URL
+	urlCredentials = regexp.MustCompile(`(://[^/:@\s]+:)[^@\s]+@`)
+	// flag nhay cam, gia tri nam o tham so ke tiep
+	secretFlag = regexp.MustCompile(`(?i)^--?[a-z_-]*(pass(word|wd)?|secret|token|api[_-]?key|access[_-]?key|auth|credentials?)$`)
+)
+
+// Ham redactCmdline an cac gia tri nhay cam trong command line truoc khi hien thi
+func redactCmdline(argv []string) string {
+	out := make([]string, len(argv))
+	for i, arg := range argv {
+		if i > 0 && secretFlag.MatchString(argv[i-1]) {
+			out[i] = "****"
+			continue
+		}
+		arg = urlCredentials.ReplaceAllString(arg, "${1}****@")
+		arg = secretAssignment.ReplaceAllString(arg, "${1}****")
+		arg = bearerToken.ReplaceAllString(arg, "${1}****")
+		out[i] = arg
+	}
+	return strings.Join(out, " ")
+}
+
+// Ham checkCmdlineRules ap dung rule command line, tra ve cac process con phai duyet theo luong binh thuong
+// va pid cua cac process khop rule allow (chi bo qua duyet theo ten, van xac minh file thuc thi)
+func checkCmdlineRules(processes []ProcessInfo) ([]ProcessInfo, map[int]bool, bool) {
+	allowed := make(map[int]bool)
+	if len(config.CmdlineRules) == 0 {
+		return processes, allowed, false
+	}
+	alerted := false
+	var pending []ProcessInfo
+	for _, p := range processes {
+		rule, ok := cmdlineRuleFor(p)
+		if !ok {
+			pending = append(pending, p)
+			continue
+		}
+		if rule.Action == "allow" {
+			allowed[p.PID] = true
+			pending = append(pending, p)
+			continue
+		}
+		if !reportedCmdline.first(p, rule.Name) {
+			continue
+		}
+		alerted = true
+		fmt.Printf("\nALERT: Command line rule %q denies %s (PID %d)\n", rule.Name, p.Name, p.PID)
+		fmt.Printf("  Command line: %s\n", redactCmdline(p.Cmdline))
+		if processTree != nil {
+			fmt.Printf("  Ancestry: %s\n", ancestryChain(p))
+		}
+		writeAudit(auditEntry{Event: "cmdline", Process: p.Name, PID: p.PID, Key: rule.Name, Result: redactCmdline(p.Cmdline)})
+		respondToDenied("cmdline:"+rule.Name, []ProcessInfo{p}, processes)
+	}
+	reportedCmdline.prune()
+	return pending, allowed, alerted
+}
+
+// Ham splitAllowed tach cac process khop rule allow ra khoi danh sach
+func splitAllowed(procs []ProcessInfo, allowed map[int]bool) ([]ProcessInfo, []ProcessInfo) {
+	var rest, ruled []ProcessInfo
+	for _, p := range procs {
+		if allowed[p.PID] {
+			ruled = append(ruled, p)
+		} else {
+			rest = append(rest, p)
+		}
+	}
+	return rest, ruled
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Rule ve nguon goc process: process con khop Processes co to tien khop cac dieu kien Ancestor*
+type LineageRule struct {
+	Name          string   `json:"name"`           // ten rule hien thi trong canh bao
+	Processes     []string `json:"processes"`      // ten process con (glob), rong = moi process
+	AncestorNames []string `json:"ancestor_names"` // ten to tien (glob)
+	AncestorPaths []string `json:"ancestor_paths"` // duong dan file thuc thi cua to tien (glob)
+	AncestorUsers []string `json:"ancestor_users"` // user hoac uid cua to tien
+	MaxDepth      int      `json:"max_depth"`      // 1 = chi process cha, 0 = moi to tien
+	Respond       bool     `json:"respond"`        // thuc hien hanh dong trong "response" voi process khop
+}
+
+// Cay process cua lan quet hien tai: pid -> process
+var processTree map[int]ProcessInfo
+
+// Da canh bao process nao theo rule nao, de khong lap lai moi lan quet
+var reportedLineage = newAlertSet()
+
+var userNames = make(map[int]string)
+
+// Ham userName tra ve ten user cua uid (co cache), hoac uid neu khong tra cuu duoc
+func userName(uid int) string {
+	if uid < 0 {
+		return "?"
+	}
+	if name, ok := userNames[uid]; ok {
+		return name
+	}
+	name := strconv.Itoa(uid)
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+	userNames[uid] = name
+	return name
+}
+
+// Ham buildProcessTree tao cay process tu danh sach process dang chay
+func buildProcessTree(processes []ProcessInfo) {
+	processTree = make(map[int]ProcessInfo, len(processes))
+	for _, p := range processes {
+		processTree[p.PID] = p
+	}
+}
+
+// Ham ancestors tra ve cac to tien cua process, tu cha len goc
+func ancestors(p ProcessInfo) []ProcessInfo {
+	var chain []ProcessInfo
+	seen := map[int]bool{p.PID: true}
+	for pid := p.PPID; pid > 0 && !seen[pid]; {
+		parent, ok := processTree[pid]
+		if !ok {
+			break
+		}
+		seen[pid] = true
+		chain = append(chain, parent)
+		pid = parent.PPID
+	}
+	return chain
+}
+
+// Ham ancestryChain mo ta chuoi nguon goc tu goc toi process
+func ancestryChain(p ProcessInfo) string {
+	chain := ancestors(p)
+	parts := make([]string, 0, len(chain)+1)
+	for i := len(chain) - 1; i >= 0; i-- {
+		parts = append(parts, chainEntry(chain[i]))
+	}
+	parts = append(parts, chainEntry(p))
+	return strings.Join(parts, " -> ")
+}
+
+func chainEntry(p ProcessInfo) string {
+	entry := fmt.Sprintf("%s[%d %s]", p.Name, p.PID, userName(p.UID))
+	if p.Exe != "" {
+		entry += " " + p.Exe
+	}
+	return entry
+}
+
+// Ham matchGlob kiem tra value khop mot trong cac pattern; danh sach rong coi nhu khop
+func matchGlob(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Chuan hoa ten trong danh sach pattern giong ten process
+func normalizeAll(patterns []string) []string {
+	names := make([]string, len(patterns))
+	for i, n := range patterns {
+		names[i] = normalizeProcessName(n)
+	}
+	return names
+}
+
+// Ham matchAncestor tra ve to tien dau tien khop rule
+func (r LineageRule) matchAncestor(p ProcessInfo) (ProcessInfo, bool) {
+	if len(r.AncestorNames) == 0 && len(r.AncestorPaths) == 0 && len(r.AncestorUsers) == 0 {
+		return ProcessInfo{}, false
+	}
+	names := normalizeAll(r.AncestorNames)
+	for depth, a := range ancestors(p) {
+		if r.MaxDepth > 0 && depth >= r.MaxDepth {
+			break
+		}
+		if !matchGlob(names, normalizeProcessName(a.Name)) || !matchGlob(r.AncestorPaths, exePath(a)) {
+			continue
+		}
+		if len(r.AncestorUsers) > 0 && !matchGlob(r.AncestorUsers, userName(a.UID)) && !matchGlob(r.AncestorUsers, strconv.Itoa(a.UID)) {
+			continue
+		}
+		return a, true
+	}
+	return ProcessInfo{}, false
+}
+
+// Ham checkLineage ap dung cac rule nguon goc cho moi process dang chay
+func checkLineage(processes []ProcessInfo) bool {
+	if len(config.LineageRules) == 0 {
+		return false
+	}
+	alerted := false
+	for _, p := range processes {
+		for _, rule := range config.LineageRules {
+			if !matchGlob(normalizeAll(rule.Processes), normalizeProcessName(p.Name)) {
+				continue
+			}
+			ancestor, ok := rule.matchAncestor(p)
+			if !ok {
+				continue
+			}
+			if !reportedLineage.first(p, rule.Name) {
+				continue
+			}
+			alerted = true
+			fmt.Printf("\nALERT: Lineage rule %q: %s (PID %d) descends from %s (PID %d)\n", rule.Name, p.Name, p.PID, ancestor.Name, ancestor.PID)
+			fmt.Printf("  Ancestry: %s\n", ancestryChain(p))
+			if len(p.Cmdline) > 0 {
+				fmt.Printf("  Command line: %s\n", redactCmdline(p.Cmdline))
+			}
+			writeAudit(auditEntry{Event: "lineage", Process: p.Name, PID: p.PID, Key: rule.Name, Result: ancestryChain(p)})
+			if rule.Respond {
+				respondToDenied("lineage:"+rule.Name, []ProcessInfo{p}, processes)
+			}
+		}
+	}
+	reportedLineage.prune()
+	return alerted
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Cau hinh chinh sach vi tri file thuc thi cua process
+type LocationConfig struct {
+	Enabled     bool     `json:"enabled"`
+	TempDirs    []string `json:"temp_dirs"`    // thu muc tam, mac dinh /tmp, /var/tmp, /dev/shm va os.TempDir()
+	TrustedDirs []string `json:"trusted_dirs"` // neu khai bao, moi process phai chay tu cac thu muc nay
+	Respond     bool     `json:"respond"`      // thuc hien hanh dong trong "response" voi process vi pham
+}
+
+func (c LocationConfig) tempDirs() []string {
+	if len(c.TempDirs) > 0 {
+		return c.TempDirs
+	}
+	dirs := []string{os.TempDir()}
+	if runtime.GOOS != "windows" {
+		dirs = append(dirs, "/tmp", "/var/tmp", "/dev/shm")
+	}
+	return dirs
+}
+
+// Da canh bao process nao vi pham chinh sach nao, de khong lap lai moi lan quet
+var reportedLocation = newAlertSet()
+
+// Ham isUnder kiem tra path nam trong dir
+func isUnder(path, dir string) bool {
+	dir = filepath.Clean(dir)
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, string(os.PathSeparator))+string(os.PathSeparator))
+}
+
+func isUnderAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if isUnder(path, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ham worldWritableDir tra ve thu muc dau tien (tu thu muc chua file len goc) ma moi user deu ghi duoc
+func worldWritableDir(path string, cache map[string]bool) string {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		writable, ok := cache[dir]
+		if !ok {
+			info, err := os.Stat(dir)
+			writable = err == nil && info.Mode().Perm()&0002 != 0
+			cache[dir] = writable
+		}
+		if writable {
+			return dir
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return ""
+		}
+	}
+}
+
+// Ham locationViolations liet ke cac vi pham chinh sach vi tri cua process
+func locationViolations(p ProcessInfo, writableCache map[string]bool) []string {
+	if p.Exe == "" {
+		return nil
+	}
+	// memfd: file thuc thi chi nam trong bo nho (fileless)
+	if strings.HasPrefix(p.Exe, "/memfd:") {
+		return []string{"executable is an anonymous memfd mapping"}
+	}
+	var violations []string
+	path := exePath(p)
+	if path != p.Exe {
+		violations = append(violations, "executable was deleted after launch")
+	}
+	if isUnderAny(path, config.Location.tempDirs()) {
+		violations = append(violations, "executable is in a temporary directory")
+	} else if dir := worldWritableDir(path, writableCache); dir != "" {
+		violations = append(violations, "executable is under world-writable directory "+dir)
+	}
+	if isUnderAny(path, config.MonitorFolder) {
+		violations = append(violations, "executable is in a monitored folder")
+	}
+	if len(config.Location.TrustedDirs) > 0 && !isUnderAny(path, config.Location.TrustedDirs) {
+		violations = append(violations, "executable is outside trusted directories")
+	}
+	return violations
+}
+
+// Ham checkLocations ap dung chinh sach vi tri cho moi process dang chay
+func checkLocations(processes []ProcessInfo) bool {
+	if !config.Location.Enabled {
+		return false
+	}
+	alerted := false
+	writableCache := make(map[string]bool)
+	for _, p := range processes {
+		if p.PID == os.Getpid() {
+			continue
+		}
+		violations := locationViolations(p, writableCache)
+		if len(violations) == 0 || !reportedLocation.first(p, strings.Join(violations, ";")) {
+			continue
+		}
+		alerted = true
+		fmt.Printf("\nALERT: Process %s (PID %d) runs from an untrusted location: %s\n", p.Name, p.PID, strings.Join(violations, ", "))
+		fmt.Printf("  Executable: %s\n", p.Exe)
+		if processTree != nil {
+			fmt.Printf("  Ancestry: %s\n", ancestryChain(p))
+		}
+		writeAudit(auditEntry{Event: "location", Process: p.Name, PID: p.PID, Key: p.Exe, Result: strings.Join(violations, ", ")})
+		if config.Location.Respond {
+			respondToDenied("location:"+p.Exe, []ProcessInfo{p}, processes)
+		}
+	}
+	reportedLocation.prune()
+	return alerted
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"runtime"
 	"strings"
 	"time"
@@ -19,11 +18,101 @@ type MonitorConfig struct {
 	BaseLineProcess  string   `json:"baseline_process"`
 	MonitorProcess   bool     `json:"monitor_process"`
 	ProcessToMonitor []string `json:"process_to_monitor"`
+
+	Allowlist AllowlistConfig `json:"allowlist"`
+	Response  ResponseConfig  `json:"response"`  // hanh dong voi process bi tu choi
+	AuditLog  string          `json:"audit_log"` // file JSON lines ghi lai cac quyet dinh va hanh dong
+
+	LineageRules []LineageRule  `json:"lineage_rules"`
+	CmdlineRules []CmdlineRule  `json:"cmdline_rules"`
+	Location     LocationConfig `json:"location"`
+	Usage        UsageConfig    `json:"usage"`
 }
 
 // Trang thai process duoc chap nhan
 type SystemBaseline struct {
-	KnownProcess map[string]bool `json:"known_process"`
+	KnownProcess  map[string]bool `json:"known_process"`
+	DeniedProcess map[string]bool `json:"denied_process,omitempty"` // process da bi tu choi, khong hoi lai
+
+	ApprovedBinaries map[string][]ApprovedBinary `json:"approved_binaries,omitempty"` // khoa baseline -> file thuc thi da duyet
+
+	LearningStarted time.Time `json:"learning_started,omitzero"` // bat dau giai doan hoc cua che do allowlist
+}
+
+// Thong tin mot process dang chay
+type ProcessInfo struct {
+	PID       int
+	PPID      int
+	UID       int // -1 neu khong xac dinh
+	Name      string
+	Exe       string
+	Cmdline   []string
+	Cwd       string
+	StartTime time.Time
+	State     string
+	CPUTime   time.Duration // tong thoi gian CPU (user + system)
+	RSS       int64         // bo nho thuc te, byte
+}
+
+// Ham describe tra ve cac dong mo ta process de hien thi trong prompt
+func (p ProcessInfo) describe() []string {
+	lines := []string{fmt.Sprintf("PID %d, parent %d", p.PID, p.PPID)}
+	if p.UID >= 0 {
+		lines = append(lines, fmt.Sprintf("UID %d", p.UID))
+	}
+	if p.Exe != "" {
+		lines = append(lines, "Executable: "+p.Exe)
+	}
+	if len(p.Cmdline) > 0 {
+		lines = append(lines, "Command line: "+redactCmdline(p.Cmdline))
+	}
+	if processTree != nil && p.PPID > 0 {
+		lines = append(lines, "Ancestry: "+ancestryChain(p))
+	}
+	if p.Cwd != "" {
+		lines = append(lines, "Working directory: "+p.Cwd)
+	}
+	if !p.StartTime.IsZero() {
+		lines = append(lines, "Started: "+p.StartTime.Format(time.RFC3339))
+	}
+	if p.State != "" {
+		lines = append(lines, "State: "+p.State)
+	}
+	if p.RSS > 0 {
+		lines = append(lines, fmt.Sprintf("CPU time %s, memory %d MB", p.CPUTime, p.RSS>>20))
+	}
+	return lines
+}
+
+// Tap canh bao da bao cho cac process dang chay (theo pid va thoi diem bat dau), bo khi process ket thuc
+type alertSet struct {
+	reported map[string]bool
+	seen     map[string]bool
+}
+
+func newAlertSet() *alertSet {
+	return &alertSet{reported: make(map[string]bool), seen: make(map[string]bool)}
+}
+
+// Ham first tra ve true neu day la lan dau canh bao process theo tag
+func (a *alertSet) first(p ProcessInfo, tag string) bool {
+	id := fmt.Sprintf("%d:%d:%s", p.PID, p.StartTime.UnixNano(), tag)
+	a.seen[id] = true
+	if a.reported[id] {
+		return false
+	}
+	a.reported[id] = true
+	return true
+}
+
+// Ham prune bo cac canh bao cua process khong con thay trong lan quet nay
+func (a *alertSet) prune() {
+	for id := range a.reported {
+		if !a.seen[id] {
+			delete(a.reported, id)
+		}
+	}
+	a.seen = make(map[string]bool)
 }
 
 var (
@@ -31,6 +120,9 @@ var (
 	baseline SystemBaseline
 )
 
+// Dung chung mot scanner cho moi prompt de khong mat du lieu da doc vao buffer
+var inputScanner = bufio.NewScanner(os.Stdin)
+
 func loadConfig(configPath string) error {
 	file, err := os.ReadFile(configPath)
 	if err != nil {
@@ -45,7 +137,10 @@ func loadConfig(configPath string) error {
 func loadBaseline() error {
 	if _, err := os.Stat(config.BaseLineProcess); os.IsNotExist(err) {
 		baseline = SystemBaseline{
-			KnownProcess: make(map[string]bool),
+			KnownProcess:  make(map[string]bool),
+			DeniedProcess: make(map[string]bool),
+
+			ApprovedBinaries: make(map[string][]ApprovedBinary),
 		}
 		return nil
 	}
@@ -56,6 +151,15 @@ func loadBaseline() error {
 	if err := json.Unmarshal(file, &baseline); err != nil {
 		return fmt.Errorf("unable to parse baseline file: %v", err)
 	}
+	if baseline.KnownProcess == nil {
+		baseline.KnownProcess = make(map[string]bool)
+	}
+	if baseline.DeniedProcess == nil {
+		baseline.DeniedProcess = make(map[string]bool)
+	}
+	if baseline.ApprovedBinaries == nil {
+		baseline.ApprovedBinaries = make(map[string][]ApprovedBinary)
+	}
 	return nil
 }
 
@@ -70,17 +174,19 @@ func saveBaseline() error {
 	return nil
 }
 
-func promptApproval(processName string) bool {
+func promptApproval(processName string, details ...string) bool {
 	fmt.Printf("\nDetect new process %s\n", processName)
-	scanner := bufio.NewScanner(os.Stdin)
+	for _, d := range details {
+		fmt.Printf("  %s\n", d)
+	}
 
 	for {
 		fmt.Print("Approval? (y/n): ")
-		if !scanner.Scan() {
+		if !inputScanner.Scan() {
 			fmt.Println("Error reading input.")
 			return false
 		}
-		response := strings.TrimSpace(scanner.Text())
+		response := strings.TrimSpace(inputScanner.Text())
 		switch strings.ToLower(response) {
 		case "y":
 			return true
@@ -100,53 +206,14 @@ func normalizeProcessName(name string) string {
 	return strings.ToLower(name)
 }
 
-// Lay cac process dang chay
-func getRunningProcesses() ([]string, error) {
-	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
-		cmd = exec.Command("tasklist", "/fo", "csv", "/nh")
-	} else {
-		cmd = exec.Command("ps", "-e", "-o", "comm=")
-	}
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("unable to get running processes: %v", err)
-	}
-
-	var processes []string
-	lines := strings.Split(string(output), "\n")
-
-	for _, line := range lines { //lap qua tung dong cmd tren tung he dieu hanh
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-		if runtime.GOOS == "windows" {
-			//tach ten process tu output dang csv
-			if parts := strings.Split(line, "\""); len(parts) >= 2 {
-				processName := strings.TrimSpace(parts[1])
-				if processName != "" {
-					processName = strings.ToLower(processName)
-					processName = strings.TrimSuffix(processName, ".exe")
-					processes = append(processes, strings.ToLower(processName))
-				}
-			}
-		} else {
-			processName := strings.ToLower(line)
-			// xu ly lay ten process
-			if strings.Contains(processName, "/") {
-				parts := strings.Split(processName, "/")
-				processName = parts[len(parts)-1]
-			}
-			processes = append(processes, processName)
-		}
-	}
-	return processes, nil
-
+// Ham monitoringConfigured kiem tra co it nhat mot kieu giam sat process duoc cau hinh
+func monitoringConfigured() bool {
+	return len(config.ProcessToMonitor) > 0 || config.Allowlist.Enabled || len(config.LineageRules) > 0 ||
+		len(config.CmdlineRules) > 0 || config.Location.Enabled || config.Usage.Enabled
 }
 
 func checkProcesses() {
-	if !config.MonitorProcess || len(config.ProcessToMonitor) == 0 {
+	if !config.MonitorProcess || !monitoringConfigured() {
 		return
 	}
 	fmt.Println("Checking processes...")
@@ -158,31 +225,76 @@ func checkProcesses() {
 		return
 	}
 
+	// process da ket thuc khong con can ghi nho hanh dong da ap dung
+	appliedResponses.prune()
+	buildProcessTree(currentProcesses)
+	if checkLineage(currentProcesses) {
+		newProcessesFound = true
+	}
+	if checkLocations(currentProcesses) {
+		newProcessesFound = true
+	}
+	if checkUsage(currentProcesses) {
+		newProcessesFound = true
+	}
+	// process khop rule deny da duoc xu ly; process khop rule allow khong can duyet theo ten
+	pending, allowed, alerted := checkCmdlineRules(currentProcesses)
+	if alerted {
+		newProcessesFound = true
+	}
+
+	// che do allowlist: moi process deu duoc so sanh voi baseline
+	if config.Allowlist.Enabled {
+		if !checkAllowlist(pending, currentProcesses, allowed) && !newProcessesFound {
+			fmt.Printf("\n No new processes found.\n")
+		}
+		return
+	}
+
 	// Tạo map các process đang chạy để kiểm tra nhanh
-	runningProcesses := make(map[string]bool)
-	for _, proc := range currentProcesses {
-		runningProcesses[normalizeProcessName(proc)] = true
+	runningProcesses := make(map[string][]ProcessInfo)
+	for _, proc := range pending {
+		name := normalizeProcessName(proc.Name)
+		runningProcesses[name] = append(runningProcesses[name], proc)
 	}
 	// Kiểm tra các process cần theo dõi
 	for _, monitoredProc := range config.ProcessToMonitor {
 		normalizedMonitoredProc := normalizeProcessName(monitoredProc) //tach cac ten process muon so sanh tren windows de so sanh process thu thap duoc
 
-		if runningProcesses[normalizedMonitoredProc] {
-			if !baseline.KnownProcess[normalizedMonitoredProc] {
+		procs := runningProcesses[normalizedMonitoredProc]
+		if !baseline.KnownProcess[normalizedMonitoredProc] {
+			// rule allow thay cho duyet theo ten, file thuc thi van phai khop
+			var ruled []ProcessInfo
+			procs, ruled = splitAllowed(procs, allowed)
+			if len(ruled) > 0 {
+				fmt.Printf("Process allowed by command line rule is running: %s\n", monitoredProc)
+				if verifyBinaries(normalizedMonitoredProc, ruled, currentProcesses) {
+					newProcessesFound = true
+				}
+			}
+		}
+		if len(procs) > 0 {
+			if baseline.DeniedProcess[normalizedMonitoredProc] {
+				// da tu choi truoc do: khong hoi lai, chi thuc hien hanh dong
+				fmt.Printf("\nALERT: Denied process is running: %s\n", monitoredProc)
+				respondToDenied(normalizedMonitoredProc, procs, currentProcesses)
+			} else if !baseline.KnownProcess[normalizedMonitoredProc] {
 				fmt.Printf("\nALERT: Monitored process is running: %s\n", monitoredProc)
 				newProcessesFound = true
-				if promptApproval(monitoredProc) {
-					baseline.KnownProcess[normalizedMonitoredProc] = true
-					if err := saveBaseline(); err != nil {
-						fmt.Printf("Error saving baseline: %v\n", err)
-					} else {
-						fmt.Printf("Process %s added to baseline\n", monitoredProc)
-					}
+				var details []string
+				for _, proc := range procs {
+					details = append(details, proc.describe()...)
+				}
+				if promptApproval(monitoredProc, details...) {
+					approveProcess(monitoredProc, normalizedMonitoredProc, procs)
 				} else {
-					fmt.Printf("Process %s is NOT approved\n", monitoredProc)
+					denyProcess(monitoredProc, normalizedMonitoredProc, procs, currentProcesses)
 				}
 			} else {
 				fmt.Printf("Approved process is running: %s\n", monitoredProc)
+				if verifyBinaries(normalizedMonitoredProc, procs, currentProcesses) {
+					newProcessesFound = true
+				}
 			}
 		}
 	}
@@ -201,6 +313,10 @@ func main() {
 		fmt.Println(err)
 		os.Exit(1)
 	}
+	if err := compileCmdlineRules(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
 	if err := loadBaseline(); err != nil { //load lại trạng thái được lưu trước đó
 		fmt.Println(err)
@@ -0,0 +1,136 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// So clock tick moi giay cua kernel (USER_HZ), gan nhu luon la 100
+const clockTicks = 100
+
+// Doc thoi diem boot tu /proc/stat (btime)
+func bootTime() time.Time {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if v, ok := strings.CutPrefix(line, "btime "); ok {
+			if sec, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				return time.Unix(sec, 0)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// Ham readProcStat doc /proc/<pid>/stat, tra ve comm va cac truong sau dau ")"
+func readProcStat(pid int) (string, []string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return "", nil, err
+	}
+	// comm co the chua dau cach va ")", nen tach theo dau ")" cuoi cung
+	s := string(data)
+	open, end := strings.IndexByte(s, '('), strings.LastIndexByte(s, ')')
+	if open < 0 || end < open {
+		return "", nil, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	return s[open+1 : end], strings.Fields(s[end+1:]), nil
+}
+
+// Ham readProcess doc thong tin mot process tu /proc/<pid>
+func readProcess(pid int, boot time.Time) (ProcessInfo, error) {
+	comm, fields, err := readProcStat(pid)
+	if err != nil {
+		return ProcessInfo{}, err
+	}
+	// fields[0] la truong thu 3 (state) trong stat
+	if len(fields) < 22 {
+		return ProcessInfo{}, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	proc := ProcessInfo{PID: pid, UID: -1, Name: comm, State: fields[0]}
+	proc.PPID, _ = strconv.Atoi(fields[1])
+	if ticks, err := strconv.ParseInt(fields[19], 10, 64); err == nil && !boot.IsZero() {
+		proc.StartTime = boot.Add(time.Duration(ticks) * time.Second / clockTicks)
+	}
+	// utime + stime (truong 14, 15) va rss tinh theo page (truong 24)
+	utime, _ := strconv.ParseInt(fields[11], 10, 64)
+	stime, _ := strconv.ParseInt(fields[12], 10, 64)
+	proc.CPUTime = time.Duration(utime+stime) * time.Second / clockTicks
+	if pages, err := strconv.ParseInt(fields[21], 10, 64); err == nil {
+		proc.RSS = pages * int64(os.Getpagesize())
+	}
+
+	dir := fmt.Sprintf("/proc/%d", pid)
+	if data, err := os.ReadFile(dir + "/status"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if v, ok := strings.CutPrefix(line, "Uid:"); ok {
+				if f := strings.Fields(v); len(f) > 0 {
+					proc.UID, _ = strconv.Atoi(f[0])
+				}
+				break
+			}
+		}
+	}
+	if data, err := os.ReadFile(dir + "/cmdline"); err == nil && len(data) > 0 {
+		proc.Cmdline = strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+	}
+	// exe/cwd can quyen doc (root hoac cung user), kernel thread khong co exe
+	proc.Exe, _ = os.Readlink(dir + "/exe")
+	proc.Cwd, _ = os.Readlink(dir + "/cwd")
+	// comm bi cat con 15 ky tu, uu tien ten file thuc thi day du
+	if proc.Exe != "" {
+		proc.Name = filepath.Base(strings.TrimSuffix(proc.Exe, " (deleted)"))
+	}
+	return proc, nil
+}
+
+// Ham sameProcess kiem tra pid van la process da thay: chua thoat, khong phai zombie,
+// va khong bi process khac dung lai pid (so thoi diem khoi dong va file thuc thi)
+func sameProcess(p ProcessInfo) bool {
+	_, fields, err := readProcStat(p.PID)
+	if err != nil || len(fields) < 20 || fields[0] == "Z" || fields[0] == "X" {
+		return false
+	}
+	if ticks, err := strconv.ParseInt(fields[19], 10, 64); err == nil && !p.StartTime.IsZero() {
+		if !bootTime().Add(time.Duration(ticks) * time.Second / clockTicks).Equal(p.StartTime) {
+			return false
+		}
+	}
+	if p.Exe != "" {
+		if exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", p.PID)); err == nil && exe != p.Exe {
+			return false
+		}
+	}
+	return true
+}
+
+// Lay cac process dang chay bang cach doc truc tiep /proc
+func getRunningProcesses() ([]ProcessInfo, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("unable to read /proc: %v", err)
+	}
+	boot := bootTime()
+	var processes []ProcessInfo
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		// process co the ket thuc giua chung, bo qua
+		proc, err := readProcess(pid, boot)
+		if err != nil {
+			continue
+		}
+		processes = append(processes, proc)
+	}
+	return processes, nil
+}
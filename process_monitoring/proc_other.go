@@ -0,0 +1,89 @@
+//go:build !linux
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Ham sameProcess kiem tra pid van chay cung ten process (khong co thoi diem khoi dong de so sanh)
+func sameProcess(p ProcessInfo) bool {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("tasklist", "/fi", fmt.Sprintf("PID eq %d", p.PID), "/fo", "csv", "/nh")
+	} else {
+		cmd = exec.Command("ps", "-o", "stat=,comm=", "-p", strconv.Itoa(p.PID))
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return false
+	}
+	line := strings.TrimSpace(string(output))
+	if runtime.GOOS == "windows" {
+		parts := strings.Split(line, "\"")
+		return len(parts) >= 4 && strings.EqualFold(strings.TrimSpace(parts[1]), p.Name) && parts[3] == strconv.Itoa(p.PID)
+	}
+	stat, comm, ok := strings.Cut(line, " ")
+	if !ok || strings.HasPrefix(stat, "Z") {
+		return false
+	}
+	return filepath.Base(strings.TrimSpace(comm)) == p.Name
+}
+
+// Lay cac process dang chay qua ps/tasklist (chi co pid, ppid, uid, rss va ten)
+func getRunningProcesses() ([]ProcessInfo, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("tasklist", "/fo", "csv", "/nh")
+	} else {
+		cmd = exec.Command("ps", "-e", "-o", "pid=,ppid=,uid=,rss=,comm=")
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get running processes: %v", err)
+	}
+
+	var processes []ProcessInfo
+	lines := strings.Split(string(output), "\n")
+
+	for _, line := range lines { //lap qua tung dong cmd tren tung he dieu hanh
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if runtime.GOOS == "windows" {
+			//tach ten process va pid tu output dang csv: "name","pid",...
+			if parts := strings.Split(line, "\""); len(parts) >= 4 {
+				processName := strings.TrimSpace(parts[1])
+				if processName != "" {
+					pid, _ := strconv.Atoi(parts[3])
+					processes = append(processes, ProcessInfo{PID: pid, UID: -1, Name: processName})
+				}
+			}
+		} else {
+			fields := strings.Fields(line)
+			if len(fields) < 5 {
+				continue
+			}
+			proc := ProcessInfo{Name: strings.Join(fields[4:], " ")}
+			proc.PID, _ = strconv.Atoi(fields[0])
+			proc.PPID, _ = strconv.Atoi(fields[1])
+			proc.UID, _ = strconv.Atoi(fields[2])
+			if kb, err := strconv.ParseInt(fields[3], 10, 64); err == nil {
+				proc.RSS = kb * 1024
+			}
+			// tren macOS comm la duong dan day du
+			if strings.Contains(proc.Name, "/") {
+				proc.Exe = proc.Name
+				proc.Name = filepath.Base(proc.Name)
+			}
+			processes = append(processes, proc)
+		}
+	}
+	return processes, nil
+}
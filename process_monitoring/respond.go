@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Cau hinh hanh dong khi process bi tu choi
+type ResponseConfig struct {
+	Action           string `json:"action"`             // "none" (mac dinh), "terminate", "suspend", "renice", "freeze"
+	KillAfterSeconds int    `json:"kill_after_seconds"` // terminate: gui SIGKILL neu process van chay sau khoang nay
+	Nice             int    `json:"nice"`               // renice: gia tri nice moi
+	CgroupDir        string `json:"cgroup_dir"`         // freeze: cgroup v2 dung de dong bang process
+	IncludeTree      bool   `json:"include_tree"`       // ap dung cho ca cac process con chau
+}
+
+func (c ResponseConfig) action() string {
+	if c.Action == "" {
+		return "none"
+	}
+	return c.Action
+}
+
+func (c ResponseConfig) killAfter() time.Duration {
+	if c.KillAfterSeconds <= 0 {
+		return 5 * time.Second
+	}
+	return time.Duration(c.KillAfterSeconds) * time.Second
+}
+
+func (c ResponseConfig) nice() int {
+	if c.Nice == 0 {
+		return 19
+	}
+	return c.Nice
+}
+
+func (c ResponseConfig) cgroupDir() string {
+	if c.CgroupDir == "" {
+		return "/sys/fs/cgroup/process_monitor_frozen"
+	}
+	return c.CgroupDir
+}
+
+// Mot dong trong audit log (JSON lines)
+type auditEntry struct {
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"` // approved, denied, response
+	Process string    `json:"process"`
+	PID     int       `json:"pid,omitempty"`
+	Key     string    `json:"key,omitempty"`
+	Action  string    `json:"action,omitempty"`
+	Result  string    `json:"result,omitempty"`
+}
+
+// Ham writeAudit ghi them mot dong vao audit log neu duoc cau hinh
+func writeAudit(entry auditEntry) {
+	if config.AuditLog == "" {
+		return
+	}
+	entry.Time = time.Now()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(config.AuditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		fmt.Printf("Warning: Cannot write audit log: %v\n", err)
+		return
+	}
+	defer f.Close()
+	f.Write(append(data, '\n'))
+}
+
+// Process da bi suspend/renice/freeze (theo pid, thoi diem bat dau va hanh dong): khong ap dung lai moi lan quet
+var appliedResponses = newAlertSet()
+
+// Ket qua khi process da thoat (hoac pid da bi process khac dung lai) truoc khi gui tin hieu
+const resultGone = "skipped: process exited or PID reused"
+
+// Ham targetProcesses tra ve process va (neu include_tree) cac process con chau, cha truoc con sau
+func targetProcesses(root ProcessInfo, all []ProcessInfo) []ProcessInfo {
+	targets := []ProcessInfo{root}
+	if !config.Response.IncludeTree {
+		return targets
+	}
+	children := make(map[int][]ProcessInfo)
+	for _, p := range all {
+		children[p.PPID] = append(children[p.PPID], p)
+	}
+	seen := map[int]bool{root.PID: true}
+	for i := 0; i < len(targets); i++ {
+		for _, child := range children[targets[i].PID] {
+			if !seen[child.PID] && child.PID != os.Getpid() {
+				seen[child.PID] = true
+				targets = append(targets, child)
+			}
+		}
+	}
+	return targets
+}
+
+// Ham terminateProcesses gui SIGTERM, cho toi kill_after roi gui SIGKILL cho process con song.
+// Truoc moi tin hieu kiem tra lai pid van la process da thay (pid co the bi dung lai khi cho)
+func terminateProcesses(targets []ProcessInfo) map[int]string {
+	results := make(map[int]string)
+	for _, p := range targets {
+		if !sameProcess(p) {
+			results[p.PID] = resultGone
+		} else if err := sendSignal(p.PID, "term"); err != nil {
+			results[p.PID] = "error: " + err.Error()
+		}
+	}
+	deadline := time.Now().Add(config.Response.killAfter())
+	for time.Now().Before(deadline) {
+		alive := false
+		for _, p := range targets {
+			if _, done := results[p.PID]; !done && sameProcess(p) {
+				alive = true
+			}
+		}
+		if !alive {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	for _, p := range targets {
+		if _, done := results[p.PID]; done {
+			continue
+		}
+		if !sameProcess(p) {
+			results[p.PID] = "terminated"
+			continue
+		}
+		if err := sendSignal(p.PID, "kill"); err != nil {
+			results[p.PID] = "error: " + err.Error()
+		} else {
+			results[p.PID] = "killed after timeout"
+		}
+	}
+	return results
+}
+
+// Ham freezeProcess chuyen process vao cgroup v2 rieng va dong bang cgroup do
+func freezeProcess(pid int) error {
+	dir := config.Response.cgroupDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("unable to create cgroup: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0644); err != nil {
+		return fmt.Errorf("unable to move process to cgroup: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.freeze"), []byte("1"), 0644); err != nil {
+		return fmt.Errorf("unable to freeze cgroup: %v", err)
+	}
+	return nil
+}
+
+// Ham respondToDenied thuc hien hanh dong da cau hinh voi cac process bi tu choi va ghi audit
+func respondToDenied(key string, procs []ProcessInfo, all []ProcessInfo) {
+	action := config.Response.action()
+	if action == "none" {
+		return
+	}
+	// danh sach process co the da cu (vi du sau khi cho nguoi dung tra loi): doc lai de tim process con
+	if config.Response.IncludeTree {
+		if fresh, err := getRunningProcesses(); err == nil {
+			all = fresh
+		}
+	}
+	for _, proc := range procs {
+		targets := []ProcessInfo{proc}
+		if sameProcess(proc) {
+			targets = targetProcesses(proc, all)
+		}
+		results := make(map[int]string)
+		switch action {
+		case "terminate":
+			results = terminateProcesses(targets)
+		case "suspend", "renice", "freeze":
+			for _, p := range targets {
+				if !appliedResponses.first(p, action) {
+					continue
+				}
+				if !sameProcess(p) {
+					results[p.PID] = resultGone
+					continue
+				}
+				var err error
+				switch action {
+				case "suspend":
+					err = sendSignal(p.PID, "stop")
+				case "renice":
+					err = reniceProcess(p.PID, config.Response.nice())
+				default:
+					err = freezeProcess(p.PID)
+				}
+				if err != nil {
+					results[p.PID] = "error: " + err.Error()
+				} else {
+					results[p.PID] = "ok"
+				}
+			}
+		default:
+			fmt.Printf("Warning: Unknown response action %q\n", action)
+			return
+		}
+		for _, p := range targets {
+			result, ok := results[p.PID]
+			if !ok {
+				continue
+			}
+			fmt.Printf("Response %s on %s (PID %d): %s\n", action, proc.Name, p.PID, result)
+			writeAudit(auditEntry{Event: "response", Process: proc.Name, PID: p.PID, Key: key, Action: action, Result: result})
+		}
+	}
+}
+
+// Ham denyProcess ghi nhan process bi tu choi vao baseline (khong hoi lai lan sau) va thuc hien hanh dong
+func denyProcess(name, key string, procs []ProcessInfo, all []ProcessInfo) {
+	baseline.DeniedProcess[key] = true
+	if err := saveBaseline(); err != nil {
+		fmt.Printf("Error saving baseline: %v\n", err)
+	}
+	fmt.Printf("Process %s is NOT approved\n", name)
+	writeAudit(auditEntry{Event: "denied", Process: name, Key: key})
+	respondToDenied(key, procs, all)
+}
+
+// Ham approveProcess them process (va file thuc thi cua no) vao baseline va ghi audit
+func approveProcess(name, key string, procs []ProcessInfo) {
+	baseline.KnownProcess[key] = true
+	recordBinaries(key, procs)
+	if err := saveBaseline(); err != nil {
+		fmt.Printf("Error saving baseline: %v\n", err)
+	} else {
+		fmt.Printf("Process %s added to baseline\n", name)
+	}
+	writeAudit(auditEntry{Event: "approved", Process: name, Key: key})
+}
@@ -0,0 +1,24 @@
+//go:build !unix
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Khong co tin hieu POSIX: chi ho tro ket thuc process
+func sendSignal(pid int, sig string) error {
+	if sig != "term" && sig != "kill" {
+		return fmt.Errorf("signal %q is not supported on this platform", sig)
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return proc.Kill()
+}
+
+func reniceProcess(pid, nice int) error {
+	return fmt.Errorf("renice is not supported on this platform")
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package main
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// Ham sendSignal gui tin hieu "term", "kill" hoac "stop" toi process
+func sendSignal(pid int, sig string) error {
+	signals := map[string]syscall.Signal{"term": syscall.SIGTERM, "kill": syscall.SIGKILL, "stop": syscall.SIGSTOP}
+	s, ok := signals[sig]
+	if !ok {
+		return fmt.Errorf("unsupported signal %q", sig)
+	}
+	return syscall.Kill(pid, s)
+}
+
+// Ham reniceProcess dat lai do uu tien cua process
+func reniceProcess(pid, nice int) error {
+	return syscall.Setpriority(syscall.PRIO_PROCESS, pid, nice)
+}
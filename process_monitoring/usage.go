@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Nguong CPU/bo nho cho mot process
+type UsageLimit struct {
+	CPUPercent float64 `json:"cpu_percent"` // % cua mot core
+	RSSMB      int64   `json:"rss_mb"`
+}
+
+// Cau hinh giam sat muc su dung CPU va bo nho
+type UsageConfig struct {
+	Enabled        bool                  `json:"enabled"`
+	CPUPercent     float64               `json:"cpu_percent"`     // nguong chung, 0 = khong kiem tra
+	RSSMB          int64                 `json:"rss_mb"`          // nguong chung, 0 = khong kiem tra
+	SustainSeconds int                   `json:"sustain_seconds"` // vuot nguong lien tuc trong khoang nay moi canh bao
+	Processes      map[string]UsageLimit `json:"processes"`       // nguong rieng theo ten process, ghi de nguong chung
+	TopN           int                   `json:"top_n"`           // so process hien thi trong tong ket
+	Respond        bool                  `json:"respond"`         // thuc hien hanh dong trong "response" voi process vuot nguong
+}
+
+func (c UsageConfig) topN() int {
+	if c.TopN <= 0 {
+		return 5
+	}
+	return c.TopN
+}
+
+// Ham limitFor tra ve nguong ap dung cho process
+func (c UsageConfig) limitFor(p ProcessInfo) UsageLimit {
+	limit := UsageLimit{CPUPercent: c.CPUPercent, RSSMB: c.RSSMB}
+	for name, l := range c.Processes {
+		if normalizeProcessName(name) != normalizeProcessName(p.Name) {
+			continue
+		}
+		if l.CPUPercent > 0 {
+			limit.CPUPercent = l.CPUPercent
+		}
+		if l.RSSMB > 0 {
+			limit.RSSMB = l.RSSMB
+		}
+	}
+	return limit
+}
+
+// Mau CPU lan quet truoc va thoi diem bat dau vuot nguong cua mot process
+type usageSample struct {
+	Name    string
+	PID     int
+	CPUTime time.Duration
+	Time    time.Time
+	CPURate float64 // % CPU giua hai lan quet
+	HasRate bool
+	RSS     int64
+
+	CPUOver, RSSOver         time.Time // bat dau vuot nguong
+	CPUReported, RSSReported bool      // da canh bao trong dot vuot nguong hien tai
+}
+
+// pid:thoi diem bat dau -> mau gan nhat
+var usageSamples = make(map[string]*usageSample)
+
+// Ham sustained cap nhat dot vuot nguong, tra ve true mot lan khi da vuot du lau
+func sustained(over bool, since *time.Time, reported *bool, now time.Time) bool {
+	if !over {
+		*since, *reported = time.Time{}, false
+		return false
+	}
+	if since.IsZero() {
+		*since = now
+	}
+	if *reported || now.Sub(*since) < time.Duration(config.Usage.SustainSeconds)*time.Second {
+		return false
+	}
+	*reported = true
+	return true
+}
+
+// Ham checkUsage lay mau CPU/RSS, canh bao khi vuot nguong du lau va in tong ket top process
+func checkUsage(processes []ProcessInfo) bool {
+	if !config.Usage.Enabled {
+		return false
+	}
+	now := time.Now()
+	alerted := false
+	seen := make(map[string]bool)
+	var samples []*usageSample
+	for _, p := range processes {
+		id := fmt.Sprintf("%d:%d", p.PID, p.StartTime.UnixNano())
+		seen[id] = true
+		s, ok := usageSamples[id]
+		if !ok {
+			s = &usageSample{Name: p.Name, PID: p.PID}
+			usageSamples[id] = s
+		} else if elapsed := now.Sub(s.Time); elapsed > 0 {
+			// CPU % tinh tren mot core giua hai lan quet
+			s.CPURate = float64(p.CPUTime-s.CPUTime) / float64(elapsed) * 100
+			s.HasRate = true
+		}
+		s.CPUTime, s.Time, s.RSS = p.CPUTime, now, p.RSS
+		samples = append(samples, s)
+
+		limit := config.Usage.limitFor(p)
+		var reasons []string
+		if sustained(s.HasRate && limit.CPUPercent > 0 && s.CPURate > limit.CPUPercent, &s.CPUOver, &s.CPUReported, now) {
+			reasons = append(reasons, fmt.Sprintf("CPU %.1f%% > %.1f%% since %s", s.CPURate, limit.CPUPercent, s.CPUOver.Format(time.RFC3339)))
+		}
+		if sustained(limit.RSSMB > 0 && p.RSS > limit.RSSMB<<20, &s.RSSOver, &s.RSSReported, now) {
+			reasons = append(reasons, fmt.Sprintf("RSS %d MB > %d MB since %s", p.RSS>>20, limit.RSSMB, s.RSSOver.Format(time.RFC3339)))
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+		alerted = true
+		fmt.Printf("\nALERT: Process %s (PID %d) exceeds usage threshold: %s\n", p.Name, p.PID, strings.Join(reasons, ", "))
+		for _, line := range p.describe() {
+			fmt.Printf("  %s\n", line)
+		}
+		writeAudit(auditEntry{Event: "usage", Process: p.Name, PID: p.PID, Result: strings.Join(reasons, ", ")})
+		if config.Usage.Respond {
+			respondToDenied("usage:"+normalizeProcessName(p.Name), []ProcessInfo{p}, processes)
+		}
+	}
+	// bo mau cua process da ket thuc
+	for id := range usageSamples {
+		if !seen[id] {
+			delete(usageSamples, id)
+		}
+	}
+	printTopUsage(samples)
+	return alerted
+}
+
+// Ham printTopUsage in cac process dung nhieu CPU va bo nho nhat
+func printTopUsage(samples []*usageSample) {
+	n := config.Usage.topN()
+	sort.Slice(samples, func(i, j int) bool { return samples[i].CPURate > samples[j].CPURate })
+	var top []string
+	for _, s := range samples {
+		if len(top) == n {
+			break
+		}
+		if !s.HasRate {
+			continue
+		}
+		top = append(top, fmt.Sprintf("%s[%d] %.1f%%", s.Name, s.PID, s.CPURate))
+	}
+	if len(top) > 0 {
+		fmt.Printf("Top CPU: %s\n", strings.Join(top, ", "))
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].RSS > samples[j].RSS })
+	top = top[:0]
+	for _, s := range samples {
+		if len(top) == n || s.RSS == 0 {
+			break
+		}
+		top = append(top, fmt.Sprintf("%s[%d] %d MB", s.Name, s.PID, s.RSS>>20))
+	}
+	if len(top) > 0 {
+		fmt.Printf("Top memory: %s\n", strings.Join(top, ", "))
+	}
+}